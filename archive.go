@@ -0,0 +1,543 @@
+package utilities
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveFormat selects the container format for CreateArchive and ExtractArchive.
+type ArchiveFormat string
+
+const (
+	// ArchiveTar is an uncompressed tar archive (.tar).
+	ArchiveTar ArchiveFormat = "tar"
+	// ArchiveTarGzip is a gzip-compressed tar archive (.tar.gz, .tgz).
+	ArchiveTarGzip ArchiveFormat = "tar.gz"
+	// ArchiveZip is a zip archive (.zip) with deflated entries.
+	ArchiveZip ArchiveFormat = "zip"
+)
+
+// ErrArchiveLimit is returned (wrapped) when an archive being extracted exceeds a limit set in
+// ArchiveOptions.
+var ErrArchiveLimit = errors.New("archive exceeds extraction limit")
+
+// maxArchiveSymlinkSize caps the size of a symlink target stored as zip entry contents.
+const maxArchiveSymlinkSize = 4096
+
+// archiveSpoolOverhead is the room left above MaxTotalSize for zip headers when spooling a zip
+// archive to a temporary file.
+const archiveSpoolOverhead = 1 << 20
+
+type (
+	// ArchiveOptions configures archive creation and extraction. Limits are checked against the bytes
+	// actually decompressed, not the sizes recorded in the archive; zero means unlimited.
+	ArchiveOptions struct {
+		// Format overrides the format otherwise derived from the file name (see ArchiveFormatFromName).
+		Format ArchiveFormat
+		// MaxFileSize limits the uncompressed size of a single entry.
+		MaxFileSize int64
+		// MaxTotalSize limits the combined uncompressed size of all entries.
+		MaxTotalSize int64
+		// MaxEntries limits the number of entries.
+		MaxEntries int
+	}
+
+	// archiveEntry is a format-neutral view of one tar or zip member being extracted.
+	archiveEntry struct {
+		name     string
+		mode     fs.FileMode
+		modTime  time.Time
+		linkname string
+		hardlink bool
+		open     func() (io.ReadCloser, error)
+	}
+
+	// archiveExtractor writes entries below an os.Root, enforcing the extraction limits.
+	archiveExtractor struct {
+		root    *os.Root
+		opts    ArchiveOptions
+		entries int
+		total   int64
+		dirs    []archiveEntry
+	}
+)
+
+// ArchiveFormatFromName picks the format for filename from its MIME type as reported by
+// MimeTypeFromExtension: application/x-tar, application/gzip (.tar.gz, .tgz) or application/zip.
+func ArchiveFormatFromName(filename string) (ArchiveFormat, error) {
+	if format, ok := archiveFormatFromMime(MimeTypeFromExtension(filename)); ok {
+		return format, nil
+	}
+	return "", fmt.Errorf("cannot determine archive format of %s", filename)
+}
+
+// CreateArchive writes every file, directory and symlink in fsys to w in the given format, keeping
+// permission bits and modification times. Symlinks are stored as links when fsys implements
+// fs.ReadLinkFS (as os.DirFS does) and skipped otherwise; other special files are skipped.
+func CreateArchive(w io.Writer, fsys fs.FS, format ArchiveFormat) error {
+	switch format {
+	case ArchiveTar:
+		return createTar(w, fsys)
+	case ArchiveTarGzip:
+		gz := gzip.NewWriter(w)
+		if err := createTar(gz, fsys); err != nil {
+			return err
+		}
+		return gz.Close()
+	case ArchiveZip:
+		return createZip(w, fsys)
+	default:
+		return fmt.Errorf("unsupported archive format %q", format)
+	}
+}
+
+// CreateArchiveFromDir archives the directory dir into filename, which is written atomically. The
+// format comes from opts.Format or the file name. filename must not be inside dir.
+func CreateArchiveFromDir(filename, dir string, opts ArchiveOptions) error {
+	format, err := archiveFormat(filename, opts)
+	if err != nil {
+		return err
+	}
+	absFile, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if pathWithin(absFile, absDir) {
+		return fmt.Errorf("archive %s must not be inside %s", filename, dir)
+	}
+	af, err := CreateAtomic(filename, 0644)
+	if err != nil {
+		return err
+	}
+	defer af.Abort()
+	if err := CreateArchive(af, os.DirFS(dir), format); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", dir, err)
+	}
+	return af.Commit()
+}
+
+// ExtractArchive extracts the archive read from r into dest, creating dest if needed. opts.Format
+// is required. Entry names that are absolute or contain ".." are rejected with ErrPathEscapes, as
+// are symlinks pointing outside dest and entries below a symlink (including one extracted earlier
+// from the same archive). All files are created through an os.Root so nothing is written outside
+// dest even via symlinks already present there. Permission bits and modification times are
+// restored; setuid, setgid and sticky bits and ownership are not. Zip archives that are not read
+// from a seekable file are spooled to a temporary file first; with MaxTotalSize set, a stream
+// longer than MaxTotalSize plus 1 MiB for headers fails with ErrArchiveLimit.
+func ExtractArchive(r io.Reader, dest string, opts ArchiveOptions) error {
+	if opts.Format == "" {
+		return errors.New("archive format is required")
+	}
+	if opts.Format != ArchiveZip {
+		return extractArchive(r, nil, 0, dest, opts)
+	}
+	if ra, ok := r.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		size, err := ra.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+		return extractArchive(nil, ra, size, dest, opts)
+	}
+	tmp, err := os.CreateTemp("", "archive-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	var limit int64 = -1
+	if opts.MaxTotalSize > 0 {
+		limit = opts.MaxTotalSize + archiveSpoolOverhead
+		r = io.LimitReader(r, limit+1)
+	}
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		return fmt.Errorf("failed to buffer zip archive: %w", err)
+	}
+	if limit >= 0 && size > limit {
+		return fmt.Errorf("%w: zip archive is larger than %d bytes", ErrArchiveLimit, limit)
+	}
+	return extractArchive(nil, tmp, size, dest, opts)
+}
+
+// ExtractArchiveFile extracts the archive file filename into dest like ExtractArchive. Without
+// opts.Format the format is taken from the file name and, failing that, from DetectMimeTypeFile.
+func ExtractArchiveFile(filename, dest string, opts ArchiveOptions) error {
+	if opts.Format == "" {
+		format, err := ArchiveFormatFromName(filename)
+		if err != nil {
+			content, derr := DetectMimeTypeFile(filename)
+			if derr != nil {
+				return derr
+			}
+			var ok bool
+			if format, ok = archiveFormatFromMime(content); !ok {
+				return fmt.Errorf("%s is not a supported archive (%s)", filename, content)
+			}
+		}
+		opts.Format = format
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return ExtractArchive(f, dest, opts)
+}
+
+func archiveFormat(filename string, opts ArchiveOptions) (ArchiveFormat, error) {
+	if opts.Format != "" {
+		return opts.Format, nil
+	}
+	return ArchiveFormatFromName(filename)
+}
+
+func archiveFormatFromMime(mimeType string) (ArchiveFormat, bool) {
+	switch mimeBase(mimeType) {
+	case "application/x-tar":
+		return ArchiveTar, true
+	case "application/gzip", "application/x-gzip":
+		return ArchiveTarGzip, true
+	case "application/zip":
+		return ArchiveZip, true
+	}
+	return "", false
+}
+
+// walkArchiveFS calls fn for every entry of fsys except the root, with the symlink target for links.
+func walkArchiveFS(fsys fs.FS, fn func(name string, fi fs.FileInfo, link string) error) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var link string
+		switch {
+		case fi.IsDir(), fi.Mode().IsRegular():
+		case fi.Mode()&fs.ModeSymlink != 0:
+			if _, ok := fsys.(fs.ReadLinkFS); !ok {
+				return nil
+			}
+			if link, err = fs.ReadLink(fsys, name); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+		return fn(name, fi, link)
+	})
+}
+
+func createTar(w io.Writer, fsys fs.FS) error {
+	tw := tar.NewWriter(w)
+	err := walkArchiveFS(fsys, func(name string, fi fs.FileInfo, link string) error {
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = name
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		return copyFSFile(tw, fsys, name)
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func createZip(w io.Writer, fsys fs.FS) error {
+	zw := zip.NewWriter(w)
+	err := walkArchiveFS(fsys, func(name string, fi fs.FileInfo, link string) error {
+		hdr, err := zip.FileInfoHeader(fi)
+		if err != nil {
+			return err
+		}
+		hdr.Name = name
+		if fi.IsDir() {
+			hdr.Name += "/"
+		} else {
+			hdr.Method = zip.Deflate
+		}
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		switch {
+		case fi.Mode().IsRegular():
+			return copyFSFile(fw, fsys, name)
+		case link != "":
+			_, err := io.WriteString(fw, link)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func copyFSFile(w io.Writer, fsys fs.FS, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// extractArchive extracts a tar stream from r, or a zip archive from ra when r is nil.
+func extractArchive(r io.Reader, ra io.ReaderAt, size int64, dest string, opts ArchiveOptions) error {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	root, err := os.OpenRoot(dest)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	x := &archiveExtractor{root: root, opts: opts}
+
+	switch opts.Format {
+	case ArchiveTar, ArchiveTarGzip:
+		if opts.Format == ArchiveTarGzip {
+			gz, err := gzip.NewReader(r)
+			if err != nil {
+				return fmt.Errorf("failed to read gzip stream: %w", err)
+			}
+			defer gz.Close()
+			r = gz
+		}
+		err = extractTar(x, tar.NewReader(r))
+	case ArchiveZip:
+		err = extractZip(x, ra, size)
+	default:
+		err = fmt.Errorf("unsupported archive format %q", opts.Format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract archive into %s: %w", dest, err)
+	}
+	return x.finish()
+}
+
+func extractTar(x *archiveExtractor, tr *tar.Reader) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		e := archiveEntry{
+			name:     hdr.Name,
+			mode:     hdr.FileInfo().Mode(),
+			modTime:  hdr.ModTime,
+			linkname: hdr.Linkname,
+			open:     func() (io.ReadCloser, error) { return io.NopCloser(tr), nil },
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeDir, tar.TypeSymlink:
+		case tar.TypeLink:
+			e.hardlink = true
+		default:
+			// devices, fifos and metadata-only records are not extracted
+			continue
+		}
+		if err := x.extract(e); err != nil {
+			return err
+		}
+	}
+}
+
+func extractZip(x *archiveExtractor, ra io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		e := archiveEntry{name: f.Name, mode: f.Mode(), modTime: f.Modified, open: f.Open}
+		if x.opts.MaxFileSize > 0 && f.UncompressedSize64 > uint64(x.opts.MaxFileSize) {
+			return fmt.Errorf("%w: %s is %d bytes", ErrArchiveLimit, f.Name, f.UncompressedSize64)
+		}
+		if e.mode&fs.ModeSymlink != 0 {
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			target, err := io.ReadAll(io.LimitReader(rc, maxArchiveSymlinkSize))
+			rc.Close()
+			if err != nil {
+				return err
+			}
+			e.linkname = string(target)
+		} else if !e.mode.IsDir() && !e.mode.IsRegular() {
+			continue
+		}
+		if err := x.extract(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveEntryPath converts an archive member name into a local path below the extraction root.
+func archiveEntryPath(name string) (string, error) {
+	cleaned := path.Clean(strings.TrimPrefix(name, "./"))
+	local := filepath.FromSlash(cleaned)
+	if strings.IndexByte(name, 0) >= 0 || !filepath.IsLocal(local) {
+		return "", fmt.Errorf("%w: archive entry %q", ErrPathEscapes, name)
+	}
+	return local, nil
+}
+
+func (x *archiveExtractor) extract(e archiveEntry) error {
+	x.entries++
+	if x.opts.MaxEntries > 0 && x.entries > x.opts.MaxEntries {
+		return fmt.Errorf("%w: more than %d entries", ErrArchiveLimit, x.opts.MaxEntries)
+	}
+	name, err := archiveEntryPath(e.name)
+	if err != nil {
+		return err
+	}
+	if name == "." {
+		return nil
+	}
+	if err := x.checkParents(name); err != nil {
+		return err
+	}
+	if err := x.root.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	switch {
+	case e.mode.IsDir():
+		// writable while extracting; the final mode is applied by finish
+		if err := x.root.MkdirAll(name, 0700); err != nil {
+			return err
+		}
+		e.name = name
+		x.dirs = append(x.dirs, e)
+		return nil
+	case e.hardlink:
+		target, err := archiveEntryPath(e.linkname)
+		if err != nil {
+			return err
+		}
+		_ = x.root.Remove(name)
+		return x.root.Link(target, name)
+	case e.mode&fs.ModeSymlink != 0:
+		// the link must resolve inside the root relative to the directory it is created in
+		target := filepath.FromSlash(e.linkname)
+		if filepath.IsAbs(target) || !filepath.IsLocal(filepath.Join(filepath.Dir(name), target)) {
+			return fmt.Errorf("%w: symlink %q -> %q", ErrPathEscapes, e.name, e.linkname)
+		}
+		_ = x.root.Remove(name)
+		return x.root.Symlink(target, name)
+	}
+
+	rc, err := e.open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_ = x.root.Remove(name)
+	f, err := x.root.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	limit := int64(-1)
+	if x.opts.MaxFileSize > 0 {
+		limit = x.opts.MaxFileSize
+	}
+	if x.opts.MaxTotalSize > 0 && (limit < 0 || x.opts.MaxTotalSize-x.total < limit) {
+		limit = x.opts.MaxTotalSize - x.total
+	}
+	var src io.Reader = rc
+	if limit >= 0 {
+		src = io.LimitReader(rc, limit+1)
+	}
+	n, err := io.Copy(f, src)
+	x.total += n
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if limit >= 0 && n > limit {
+		return fmt.Errorf("%w: %s", ErrArchiveLimit, e.name)
+	}
+	if err := x.root.Chmod(name, e.mode.Perm()); err != nil {
+		return err
+	}
+	return x.root.Chtimes(name, e.modTime, e.modTime)
+}
+
+// checkParents rejects an entry whose parent directories include a symlink, whether extracted
+// earlier from the archive or already present in dest. The symlink check in extract only looks at
+// the entry's path text, which is sound only when no parent is a link.
+func (x *archiveExtractor) checkParents(name string) error {
+	dir := ""
+	for _, elem := range strings.Split(filepath.Dir(name), string(filepath.Separator)) {
+		if elem == "." {
+			break
+		}
+		dir = filepath.Join(dir, elem)
+		fi, err := x.root.Lstat(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("%w: archive entry %q is below symlink %q", ErrPathEscapes, name, filepath.ToSlash(dir))
+		}
+	}
+	return nil
+}
+
+// finish applies directory modes and times deepest first, after their contents were written.
+func (x *archiveExtractor) finish() error {
+	for i := len(x.dirs) - 1; i >= 0; i-- {
+		d := x.dirs[i]
+		if err := x.root.Chmod(d.name, d.mode.Perm()); err != nil {
+			return err
+		}
+		if err := x.root.Chtimes(d.name, d.modTime, d.modTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package utilities_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func makeArchiveSource(t *testing.T) string {
+	t.Helper()
+	src := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(src, "bin"), 0755)
+	_ = os.WriteFile(filepath.Join(src, "readme.txt"), []byte("hello"), 0644)
+	_ = os.WriteFile(filepath.Join(src, "secret.key"), []byte("key"), 0600)
+	_ = os.WriteFile(filepath.Join(src, "bin", "run.sh"), []byte("#!/bin/sh\n"), 0755)
+	_ = os.Symlink("../readme.txt", filepath.Join(src, "bin", "readme"))
+	_ = os.Chmod(filepath.Join(src, "bin"), 0750)
+	mtime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	_ = os.Chtimes(filepath.Join(src, "readme.txt"), mtime, mtime)
+	return src
+}
+
+func TestArchive_RoundTripFormats(t *testing.T) {
+	src := makeArchiveSource(t)
+	for _, name := range []string{"backup.tar", "backup.tar.gz", "backup.tgz", "backup.zip"} {
+		t.Run(name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), name)
+			if err := utilities.CreateArchiveFromDir(archive, src, utilities.ArchiveOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			dest := filepath.Join(t.TempDir(), "out")
+			if err := utilities.ExtractArchiveFile(archive, dest, utilities.ArchiveOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if b, _ := os.ReadFile(filepath.Join(dest, "bin", "readme")); string(b) != "hello" {
+				t.Errorf("symlink contents=%q", b)
+			}
+			if link, _ := os.Readlink(filepath.Join(dest, "bin", "readme")); link != "../readme.txt" {
+				t.Errorf("symlink target=%q", link)
+			}
+			for rel, want := range map[string]os.FileMode{"secret.key": 0600, "bin/run.sh": 0755, "bin": 0750 | os.ModeDir} {
+				fi, err := os.Stat(filepath.Join(dest, rel))
+				if err != nil || fi.Mode() != want {
+					t.Errorf("%s mode=%v err=%v, want %v", rel, fi.Mode(), err, want)
+				}
+			}
+			fi, _ := os.Stat(filepath.Join(dest, "readme.txt"))
+			if !fi.ModTime().Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+				t.Errorf("mtime=%v", fi.ModTime())
+			}
+		})
+	}
+}
+
+func TestArchive_FromFSAndStream(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":     {Data: []byte("alpha"), Mode: 0640},
+		"dir/b.txt": {Data: []byte("bravo"), Mode: 0644},
+	}
+	var buf bytes.Buffer
+	if err := utilities.CreateArchive(&buf, fsys, utilities.ArchiveZip); err != nil {
+		t.Fatal(err)
+	}
+	dest := t.TempDir()
+	// a non-seekable reader forces the zip to be spooled
+	if err := utilities.ExtractArchive(io.MultiReader(&buf), dest, utilities.ArchiveOptions{Format: utilities.ArchiveZip}); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dest, "dir", "b.txt")); string(b) != "bravo" {
+		t.Errorf("b.txt=%q", b)
+	}
+	if fi, _ := os.Stat(filepath.Join(dest, "a.txt")); fi == nil || fi.Mode().Perm() != 0640 {
+		t.Errorf("a.txt mode=%v", fi)
+	}
+	if err := utilities.ExtractArchive(strings.NewReader(""), dest, utilities.ArchiveOptions{}); err == nil {
+		t.Error("expected error without a format")
+	}
+}
+
+func TestArchive_RejectsTraversal(t *testing.T) {
+	tarWith := func(hdrs ...*tar.Header) *bytes.Buffer {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, h := range hdrs {
+			_ = tw.WriteHeader(h)
+			if h.Typeflag == tar.TypeReg {
+				_, _ = tw.Write(make([]byte, h.Size))
+			}
+		}
+		_ = tw.Close()
+		return &buf
+	}
+	cases := map[string]*bytes.Buffer{
+		"dotdot":   tarWith(&tar.Header{Name: "../evil.txt", Typeflag: tar.TypeReg, Size: 1, Mode: 0644}),
+		"absolute": tarWith(&tar.Header{Name: "/tmp/evil.txt", Typeflag: tar.TypeReg, Size: 1, Mode: 0644}),
+		"symlink": tarWith(
+			&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "../..", Mode: 0777},
+			&tar.Header{Name: "link/evil.txt", Typeflag: tar.TypeReg, Size: 1, Mode: 0644},
+		),
+		"hardlink": tarWith(&tar.Header{Name: "hl", Typeflag: tar.TypeLink, Linkname: "../../etc/passwd"}),
+		"chained symlink": tarWith(
+			&tar.Header{Name: "a/", Typeflag: tar.TypeDir, Mode: 0755},
+			&tar.Header{Name: "a/b", Typeflag: tar.TypeSymlink, Linkname: "..", Mode: 0777},
+			&tar.Header{Name: "a/b/c", Typeflag: tar.TypeSymlink, Linkname: "..", Mode: 0777},
+			&tar.Header{Name: "a/b/c/evil.txt", Typeflag: tar.TypeReg, Size: 1, Mode: 0644},
+		),
+	}
+	for name, archive := range cases {
+		parent := t.TempDir()
+		dest := filepath.Join(parent, "a", "b")
+		err := utilities.ExtractArchive(archive, dest, utilities.ArchiveOptions{Format: utilities.ArchiveTar})
+		if !errors.Is(err, utilities.ErrPathEscapes) {
+			t.Errorf("%s: err=%v, want ErrPathEscapes", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(parent, "a", "evil.txt")); err == nil {
+			t.Errorf("%s: file written outside destination", name)
+		}
+		if target, err := filepath.EvalSymlinks(filepath.Join(dest, "c")); err == nil && !strings.HasPrefix(target, dest) {
+			t.Errorf("%s: link resolves outside destination: %s", name, target)
+		}
+	}
+
+	// a symlink already present in the destination cannot be used to escape either
+	parent := t.TempDir()
+	dest := filepath.Join(parent, "dest")
+	_ = os.MkdirAll(dest, 0755)
+	_ = os.Symlink(parent, filepath.Join(dest, "out"))
+	err := utilities.ExtractArchive(tarWith(&tar.Header{Name: "out/evil.txt", Typeflag: tar.TypeReg, Size: 1, Mode: 0644}), dest, utilities.ArchiveOptions{Format: utilities.ArchiveTar})
+	if err == nil {
+		t.Error("expected error writing through a pre-existing escaping symlink")
+	}
+	if _, err := os.Stat(filepath.Join(parent, "evil.txt")); err == nil {
+		t.Error("file written through pre-existing symlink")
+	}
+
+	var zbuf bytes.Buffer
+	zw := zip.NewWriter(&zbuf)
+	w, _ := zw.Create("../../zip-slip.txt")
+	_, _ = w.Write([]byte("x"))
+	_ = zw.Close()
+	err = utilities.ExtractArchive(bytes.NewReader(zbuf.Bytes()), t.TempDir(), utilities.ArchiveOptions{Format: utilities.ArchiveZip})
+	if !errors.Is(err, utilities.ErrPathEscapes) {
+		t.Errorf("zip-slip: err=%v", err)
+	}
+}
+
+func TestArchive_Limits(t *testing.T) {
+	fsys := fstest.MapFS{
+		"big.bin":   {Data: bytes.Repeat([]byte{0}, 1<<20), Mode: 0644},
+		"small.txt": {Data: []byte("ok"), Mode: 0644},
+	}
+	for _, format := range []utilities.ArchiveFormat{utilities.ArchiveTarGzip, utilities.ArchiveZip} {
+		var buf bytes.Buffer
+		if err := utilities.CreateArchive(&buf, fsys, format); err != nil {
+			t.Fatal(err)
+		}
+		for _, opts := range []utilities.ArchiveOptions{
+			{Format: format, MaxFileSize: 1 << 10},
+			{Format: format, MaxTotalSize: 1 << 19},
+			{Format: format, MaxEntries: 1},
+		} {
+			err := utilities.ExtractArchive(bytes.NewReader(buf.Bytes()), t.TempDir(), opts)
+			if !errors.Is(err, utilities.ErrArchiveLimit) {
+				t.Errorf("%s %+v: err=%v, want ErrArchiveLimit", format, opts, err)
+			}
+		}
+		if err := utilities.ExtractArchive(bytes.NewReader(buf.Bytes()), t.TempDir(), utilities.ArchiveOptions{Format: format, MaxFileSize: 1 << 20, MaxTotalSize: 2 << 20, MaxEntries: 2}); err != nil {
+			t.Errorf("%s within limits: %v", format, err)
+		}
+	}
+	// a non-seekable zip stream is only spooled up to the total size limit plus header room
+	stream := io.MultiReader(bytes.NewReader(make([]byte, 4<<20)))
+	err := utilities.ExtractArchive(stream, t.TempDir(), utilities.ArchiveOptions{Format: utilities.ArchiveZip, MaxTotalSize: 1 << 10})
+	if !errors.Is(err, utilities.ErrArchiveLimit) {
+		t.Errorf("oversized zip stream: err=%v, want ErrArchiveLimit", err)
+	}
+}
+
+func TestArchiveFormatFromName(t *testing.T) {
+	cases := map[string]utilities.ArchiveFormat{
+		"a.tar": utilities.ArchiveTar, "a.tar.gz": utilities.ArchiveTarGzip, "A.TGZ": utilities.ArchiveTarGzip, "a.zip": utilities.ArchiveZip,
+	}
+	for name, want := range cases {
+		if got, err := utilities.ArchiveFormatFromName(name); err != nil || got != want {
+			t.Errorf("%s: %q %v", name, got, err)
+		}
+	}
+	if _, err := utilities.ArchiveFormatFromName("a.txt"); err == nil {
+		t.Error("expected error for a.txt")
+	}
+
+	// without a usable extension the format is sniffed from the contents
+	src := makeArchiveSource(t)
+	archive := filepath.Join(t.TempDir(), "upload")
+	if err := utilities.CreateArchiveFromDir(archive, src, utilities.ArchiveOptions{Format: utilities.ArchiveTarGzip}); err != nil {
+		t.Fatal(err)
+	}
+	dest := t.TempDir()
+	if err := utilities.ExtractArchiveFile(archive, dest, utilities.ArchiveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "bin", "run.sh")); err != nil {
+		t.Error(err)
+	}
+	if err := utilities.CreateArchiveFromDir(filepath.Join(src, "self.zip"), src, utilities.ArchiveOptions{}); err == nil {
+		t.Error("expected error for an archive inside its source directory")
+	}
+}
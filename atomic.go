@@ -0,0 +1,138 @@
+package utilities
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// AtomicFile is a file whose contents replace filename only when Commit succeeds. Data is written to
+// a temporary file in the same directory, which is synced and renamed over the target, and the
+// directory is synced afterwards, so readers and crashes see either the old or the new contents.
+type AtomicFile struct {
+	*os.File
+	target string
+	// mode is the existing target's mode, applied again at Commit because writes by non-root users
+	// clear the setuid and setgid bits
+	mode fs.FileMode
+	done bool
+}
+
+// CreateAtomic starts an atomic write of filename. An existing file's permissions (including the
+// setuid, setgid and sticky bits) and, as far as the caller may set them, owner and group are
+// carried over to the replacement; otherwise the file is created with perm less the umask, as
+// os.WriteFile does. When filename is a symlink, its target is replaced and the link is kept. Call
+// Commit to install the file or Abort to discard it.
+func CreateAtomic(filename string, perm os.FileMode) (*AtomicFile, error) {
+	target := filename
+	if resolved, err := filepath.EvalSymlinks(filename); err == nil {
+		target = resolved
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	f, err := createTempPerm(filepath.Dir(target), "."+filepath.Base(target)+".tmp-", perm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file for %s: %w", filename, err)
+	}
+	af := &AtomicFile{File: f, target: target}
+
+	if fi, err := os.Stat(target); err == nil {
+		if err := preserveOwner(f, fi); err != nil {
+			af.Abort()
+			return nil, fmt.Errorf("failed to preserve ownership of %s: %w", filename, err)
+		}
+		// after the chown, which clears setuid and setgid
+		af.mode = fi.Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+		if err := f.Chmod(af.mode); err != nil {
+			af.Abort()
+			return nil, fmt.Errorf("failed to set permissions for %s: %w", filename, err)
+		}
+	}
+	return af, nil
+}
+
+// Commit syncs and closes the temporary file, renames it over the target and syncs the directory.
+// On failure the temporary file is removed and the target is left unchanged.
+func (a *AtomicFile) Commit() error {
+	if a.done {
+		return fmt.Errorf("atomic write of %s already finished", a.target)
+	}
+	a.done = true
+	tmp := a.Name()
+	if a.mode&(fs.ModeSetuid|fs.ModeSetgid) != 0 {
+		if err := a.File.Chmod(a.mode); err != nil {
+			a.File.Close()
+			os.Remove(tmp)
+			return fmt.Errorf("failed to set permissions for %s: %w", a.target, err)
+		}
+	}
+	if err := a.File.Sync(); err != nil {
+		a.File.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to sync %s: %w", tmp, err)
+	}
+	if err := a.File.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, a.target); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", a.target, err)
+	}
+	return syncDir(filepath.Dir(a.target))
+}
+
+// Abort discards the temporary file. It is a no-op after Commit, so it can be deferred.
+func (a *AtomicFile) Abort() {
+	if a.done {
+		return
+	}
+	a.done = true
+	a.File.Close()
+	os.Remove(a.Name())
+}
+
+// WriteFileAtomic is an atomic os.WriteFile: filename ends up with either its old contents or data,
+// never a partial write. perm applies only when the file does not exist yet.
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	af, err := CreateAtomic(filename, perm)
+	if err != nil {
+		return err
+	}
+	defer af.Abort()
+	if _, err := af.Write(data); err != nil {
+		return err
+	}
+	return af.Commit()
+}
+
+// WriteReaderAtomic atomically replaces filename with everything read from r.
+func WriteReaderAtomic(filename string, r io.Reader, perm os.FileMode) error {
+	af, err := CreateAtomic(filename, perm)
+	if err != nil {
+		return err
+	}
+	defer af.Abort()
+	if _, err := io.Copy(af, r); err != nil {
+		return err
+	}
+	return af.Commit()
+}
+
+// createTempPerm is os.CreateTemp with a caller-chosen permission, so the kernel applies the
+// umask to new files exactly as for os.WriteFile.
+func createTempPerm(dir, prefix string, perm os.FileMode) (*os.File, error) {
+	for range 10000 {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(rand.Uint64(), 36))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if !errors.Is(err, fs.ErrExist) {
+			return f, err
+		}
+	}
+	return nil, &fs.PathError{Op: "createtemp", Path: filepath.Join(dir, prefix+"*"), Err: fs.ErrExist}
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package utilities
+
+import (
+	"io/fs"
+	"os"
+)
+
+// preserveOwner is a no-op: file ownership is not expressed as uid/gid on this platform.
+func preserveOwner(*os.File, fs.FileInfo) error {
+	return nil
+}
+
+// syncDir is a no-op: directories cannot be opened for syncing on this platform.
+func syncDir(string) error {
+	return nil
+}
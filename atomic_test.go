@@ -0,0 +1,99 @@
+package utilities_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func TestWriteFileAtomic_PreservesModeAndLeavesNoTemp(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := utilities.WriteFileAtomic(path, []byte("one"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0600 {
+		t.Errorf("new file mode=%v", fi.Mode().Perm())
+	}
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := utilities.WriteFileAtomic(path, []byte("two"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	fi, _ := os.Stat(path)
+	if string(data) != "two" || fi.Mode().Perm() != 0640 {
+		t.Errorf("data=%q mode=%v", data, fi.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestCreateAtomic_AbortKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	af, err := utilities.CreateAtomic(path, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = af.WriteString("partial")
+	af.Abort()
+	if data, _ := os.ReadFile(path); string(data) != "old" {
+		t.Errorf("aborted write changed file: %q", data)
+	}
+	if err := af.Commit(); err == nil {
+		t.Errorf("Commit after Abort should fail")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestWriteFileAtomic_FollowsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "real.json")
+	link := filepath.Join(dir, "link.json")
+	if err := os.WriteFile(target, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := utilities.WriteReaderAtomic(link, strings.NewReader(`{"a":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Lstat(link); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("symlink was replaced")
+	}
+	if data, _ := os.ReadFile(target); string(data) != `{"a":1}` {
+		t.Errorf("target=%q", data)
+	}
+}
+
+func TestWriteFileAtomic_MissingDir(t *testing.T) {
+	err := utilities.WriteFileAtomic(filepath.Join(t.TempDir(), "nope", "f"), nil, 0644)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected not-exist error, got %v", err)
+	}
+}
+
+func TestToJSONFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v.json")
+	if err := utilities.ToJSONFileAtomic(map[string]int{"a": 1}, path); err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]int
+	if err := utilities.FromJSONFile(path, &out); err != nil || out["a"] != 1 {
+		t.Errorf("out=%v err=%v", out, err)
+	}
+}
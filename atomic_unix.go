@@ -0,0 +1,43 @@
+//go:build unix
+
+package utilities
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// preserveOwner gives f the owner and group recorded in fi. Only root can give files away, so on a
+// permission error only the group is set, which works when the caller is a member of it; failing
+// that the file keeps the caller's ownership.
+func preserveOwner(f *os.File, fi fs.FileInfo) error {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	err := f.Chown(int(st.Uid), int(st.Gid))
+	if errors.Is(err, fs.ErrPermission) {
+		err = f.Chown(-1, int(st.Gid))
+	}
+	if err != nil && !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+	return nil
+}
+
+// syncDir flushes a directory entry change (create, rename) to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	// some filesystems do not support syncing directories
+	if err := d.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTSUP) {
+		return fmt.Errorf("failed to sync directory %s: %w", dir, err)
+	}
+	return nil
+}
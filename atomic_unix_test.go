@@ -0,0 +1,50 @@
+//go:build unix
+
+package utilities_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func TestWriteFileAtomic_HonorsUmask(t *testing.T) {
+	old := syscall.Umask(077)
+	defer syscall.Umask(old)
+	dir := t.TempDir()
+	if err := utilities.ToJSONFileAtomic(map[string]int{"a": 1}, filepath.Join(dir, "atomic.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := utilities.ToJSONFile(map[string]int{"a": 1}, filepath.Join(dir, "plain.json")); err != nil {
+		t.Fatal(err)
+	}
+	atomic, _ := os.Stat(filepath.Join(dir, "atomic.json"))
+	plain, _ := os.Stat(filepath.Join(dir, "plain.json"))
+	if atomic.Mode().Perm() != 0600 || atomic.Mode() != plain.Mode() {
+		t.Errorf("atomic mode=%v, os.WriteFile mode=%v", atomic.Mode(), plain.Mode())
+	}
+}
+
+func TestWriteFileAtomic_KeepsSpecialBitsAndGroup(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "tool")
+	if err := os.WriteFile(name, []byte("v1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(name, 0755|os.ModeSetuid|os.ModeSetgid); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.Stat(name)
+	if err := utilities.WriteFileAtomic(name, []byte("v2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.Stat(name)
+	if err != nil || after.Mode() != before.Mode() {
+		t.Fatalf("mode=%v want %v err=%v", after.Mode(), before.Mode(), err)
+	}
+	if after.Sys().(*syscall.Stat_t).Gid != before.Sys().(*syscall.Stat_t).Gid {
+		t.Errorf("group not preserved")
+	}
+}
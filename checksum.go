@@ -0,0 +1,416 @@
+package utilities
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// HashAlgorithm names a checksum algorithm; digests are lowercase hex as printed by the matching
+// coreutils tool (sha256sum, sha512sum, b2sum) or, for CRC32, the big-endian IEEE checksum.
+type HashAlgorithm string
+
+const (
+	// HashSHA256 is SHA-256, matching sha256sum.
+	HashSHA256 HashAlgorithm = "sha256"
+	// HashSHA512 is SHA-512, matching sha512sum.
+	HashSHA512 HashAlgorithm = "sha512"
+	// HashBLAKE2b is BLAKE2b-512, matching b2sum's default output.
+	HashBLAKE2b HashAlgorithm = "blake2b"
+	// HashCRC32 is the IEEE CRC-32 as 8 hex digits; it detects corruption, not tampering.
+	HashCRC32 HashAlgorithm = "crc32"
+)
+
+// ErrChecksumMismatch is returned (wrapped) when data does not match its expected digest.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// checksumBufferSize is the read size for streaming hashes and the granularity of progress reports.
+const checksumBufferSize = 1 << 20
+
+type (
+	// ChecksumProgress receives the number of bytes hashed so far and the total, or -1 when the total
+	// is unknown. It is called after every chunk read and once more at the end.
+	ChecksumProgress func(done, total int64)
+
+	// ChecksumEntry is one line of a checksum manifest: a digest and a slash-separated path relative
+	// to the manifest's root directory.
+	ChecksumEntry struct {
+		Digest string
+		Path   string
+	}
+
+	// ChecksumFailure describes a manifest entry that failed verification. Err wraps
+	// ErrChecksumMismatch, fs.ErrNotExist or the error that prevented reading the file.
+	ChecksumFailure struct {
+		Path     string
+		Expected string
+		Actual   string
+		Err      error
+	}
+
+	// progressReader counts bytes read for a ChecksumProgress. It deliberately does not implement
+	// io.WriterTo so io.CopyBuffer uses the large buffer.
+	progressReader struct {
+		r        io.Reader
+		done     int64
+		total    int64
+		base     int64
+		progress ChecksumProgress
+	}
+)
+
+// New returns a new hash.Hash for the algorithm.
+func (a HashAlgorithm) New() (hash.Hash, error) {
+	switch a {
+	case HashSHA256:
+		return sha256.New(), nil
+	case HashSHA512:
+		return sha512.New(), nil
+	case HashBLAKE2b:
+		return blake2b.New512(nil)
+	case HashCRC32:
+		return crc32.NewIEEE(), nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %q", a)
+	}
+}
+
+// Checksum hashes everything read from r and returns the hex digest.
+func Checksum(r io.Reader, algo HashAlgorithm, progress ChecksumProgress) (string, error) {
+	sums, err := MultiChecksum(r, -1, progress, algo)
+	if err != nil {
+		return "", err
+	}
+	return sums[algo], nil
+}
+
+// MultiChecksum hashes r with several algorithms in a single pass. size is passed through to
+// progress as the total and may be -1 when unknown.
+func MultiChecksum(r io.Reader, size int64, progress ChecksumProgress, algos ...HashAlgorithm) (map[HashAlgorithm]string, error) {
+	hashes := make(map[HashAlgorithm]hash.Hash, len(algos))
+	writers := make([]io.Writer, 0, len(algos))
+	for _, algo := range algos {
+		h, err := algo.New()
+		if err != nil {
+			return nil, err
+		}
+		hashes[algo] = h
+		writers = append(writers, h)
+	}
+	if len(writers) == 0 {
+		return nil, errors.New("no hash algorithm given")
+	}
+	pr := &progressReader{r: r, total: size, progress: progress}
+	if _, err := io.CopyBuffer(io.MultiWriter(writers...), pr, make([]byte, checksumBufferSize)); err != nil {
+		return nil, fmt.Errorf("failed to hash data: %w", err)
+	}
+	pr.report()
+	sums := make(map[HashAlgorithm]string, len(hashes))
+	for algo, h := range hashes {
+		sums[algo] = hex.EncodeToString(h.Sum(nil))
+	}
+	return sums, nil
+}
+
+// ChecksumFile hashes the file at path; progress totals are the file size.
+func ChecksumFile(path string, algo HashAlgorithm, progress ChecksumProgress) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return checksumOpenFile(f, algo, 0, -1, progress)
+}
+
+// VerifyChecksum hashes r and returns an error wrapping ErrChecksumMismatch unless the digest
+// equals expected (compared case-insensitively).
+func VerifyChecksum(r io.Reader, algo HashAlgorithm, expected string, progress ChecksumProgress) error {
+	actual, err := Checksum(r, algo, progress)
+	if err != nil {
+		return err
+	}
+	return compareDigest("data", expected, actual)
+}
+
+// VerifyChecksumFile is VerifyChecksum for the file at path.
+func VerifyChecksumFile(path string, algo HashAlgorithm, expected string, progress ChecksumProgress) error {
+	actual, err := ChecksumFile(path, algo, progress)
+	if err != nil {
+		return err
+	}
+	return compareDigest(path, expected, actual)
+}
+
+// ChecksumTree hashes every regular file below root and returns entries sorted by path. Symlinks
+// and other special files are skipped. Progress covers the combined size of all files.
+func ChecksumTree(root string, algo HashAlgorithm, progress ChecksumProgress) ([]ChecksumEntry, error) {
+	var paths []string
+	var total int64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += fi.Size()
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", root, err)
+	}
+	entries := make([]ChecksumEntry, 0, len(paths))
+	var done int64
+	for _, path := range paths {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil, err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		digest, err := checksumOpenFile(f, algo, done, total, progress)
+		if fi, serr := f.Stat(); serr == nil {
+			done += fi.Size()
+		}
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", path, err)
+		}
+		entries = append(entries, ChecksumEntry{Digest: digest, Path: filepath.ToSlash(rel)})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// WriteChecksumManifest writes entries in the text format of sha256sum and friends:
+// "<digest>  <path>" per line, with names containing a backslash or newline escaped the way
+// GNU coreutils does, so the output can be checked with e.g. `sha256sum -c`.
+func WriteChecksumManifest(w io.Writer, entries []ChecksumEntry) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		name := e.Path
+		prefix := ""
+		if strings.ContainsAny(name, "\\\n\r") {
+			prefix = `\`
+			name = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`).Replace(name)
+		}
+		if _, err := fmt.Fprintf(bw, "%s%s  %s\n", prefix, e.Digest, name); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadChecksumManifest parses a sha256sum-style manifest. Both the text ("  ") and binary (" *")
+// separators and escaped names are accepted; blank lines and lines starting with '#' are ignored.
+func ReadChecksumManifest(r io.Reader) ([]ChecksumEntry, error) {
+	var entries []ChecksumEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		escaped := strings.HasPrefix(line, `\`)
+		if escaped {
+			line = line[1:]
+		}
+		digest, name, ok := strings.Cut(line, " ")
+		if !ok || digest == "" || len(name) < 2 || (name[0] != ' ' && name[0] != '*') {
+			return nil, fmt.Errorf("invalid checksum manifest line %d", lineNo)
+		}
+		if _, err := hex.DecodeString(digest); err != nil {
+			return nil, fmt.Errorf("invalid digest on checksum manifest line %d: %w", lineNo, err)
+		}
+		name = name[1:]
+		if escaped {
+			name = unescapeManifestName(name)
+		}
+		entries = append(entries, ChecksumEntry{Digest: strings.ToLower(digest), Path: name})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checksum manifest: %w", err)
+	}
+	return entries, nil
+}
+
+// CreateChecksumManifest hashes the tree at root with ChecksumTree and atomically writes the
+// manifest to filename. A manifest stored inside root is not listed in itself.
+func CreateChecksumManifest(root, filename string, algo HashAlgorithm, progress ChecksumProgress) error {
+	entries, err := ChecksumTree(root, algo, progress)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(root, filename); err == nil {
+		rel = filepath.ToSlash(rel)
+		filtered := entries[:0]
+		for _, e := range entries {
+			if e.Path != rel {
+				filtered = append(filtered, e)
+			}
+		}
+		entries = filtered
+	}
+	var sb strings.Builder
+	if err := WriteChecksumManifest(&sb, entries); err != nil {
+		return err
+	}
+	return WriteFileAtomic(filename, []byte(sb.String()), 0644)
+}
+
+// VerifyChecksumTree checks entries against the files below root and returns the entries that are
+// missing, unreadable or do not match. Paths are opened through an os.Root and must be local, so a
+// manifest cannot reference files outside root. The error wraps ErrChecksumMismatch when any entry failed.
+func VerifyChecksumTree(root string, entries []ChecksumEntry, algo HashAlgorithm, progress ChecksumProgress) ([]ChecksumFailure, error) {
+	r, err := os.OpenRoot(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", root, err)
+	}
+	defer r.Close()
+	var total, done int64
+	for _, e := range entries {
+		if fi, err := r.Stat(filepath.FromSlash(e.Path)); err == nil {
+			total += fi.Size()
+		}
+	}
+	var failures []ChecksumFailure
+	for _, e := range entries {
+		failure := ChecksumFailure{Path: e.Path, Expected: strings.ToLower(e.Digest)}
+		rel := filepath.FromSlash(e.Path)
+		var f *os.File
+		if filepath.IsLocal(rel) {
+			f, err = openInRoot(r, rel, e.Path)
+		} else {
+			err = fmt.Errorf("%w: %q", ErrPathEscapes, e.Path)
+		}
+		if err != nil {
+			failure.Err = err
+			failures = append(failures, failure)
+			continue
+		}
+		failure.Actual, err = checksumOpenFile(f, algo, done, total, progress)
+		if fi, serr := f.Stat(); serr == nil {
+			done += fi.Size()
+		}
+		f.Close()
+		if err == nil {
+			err = compareDigest(e.Path, failure.Expected, failure.Actual)
+		}
+		if err != nil {
+			failure.Err = err
+			failures = append(failures, failure)
+		}
+	}
+	if len(failures) > 0 {
+		return failures, fmt.Errorf("%w: %d of %d files failed verification", ErrChecksumMismatch, len(failures), len(entries))
+	}
+	return nil, nil
+}
+
+// VerifyChecksumManifest reads the manifest file and verifies it with VerifyChecksumTree. Relative
+// paths in the manifest are resolved against root, which defaults to the manifest's directory.
+func VerifyChecksumManifest(filename, root string, algo HashAlgorithm, progress ChecksumProgress) ([]ChecksumFailure, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ReadChecksumManifest(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if root == "" {
+		root = filepath.Dir(filename)
+	}
+	return VerifyChecksumTree(root, entries, algo, progress)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.done += int64(n)
+		p.report()
+	}
+	return n, err
+}
+
+func (p *progressReader) report() {
+	if p.progress != nil {
+		p.progress(p.base+p.done, p.total)
+	}
+}
+
+// checksumOpenFile hashes f, reporting progress offset by base against total; a negative total is
+// replaced by the file size.
+func checksumOpenFile(f *os.File, algo HashAlgorithm, base, total int64, progress ChecksumProgress) (string, error) {
+	if total < 0 {
+		fi, err := f.Stat()
+		if err != nil {
+			return "", err
+		}
+		total = fi.Size()
+	}
+	h, err := algo.New()
+	if err != nil {
+		return "", err
+	}
+	pr := &progressReader{r: f, base: base, total: total, progress: progress}
+	if _, err := io.CopyBuffer(h, pr, make([]byte, checksumBufferSize)); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", f.Name(), err)
+	}
+	pr.report()
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func compareDigest(name, expected, actual string) error {
+	if !strings.EqualFold(strings.TrimSpace(expected), actual) {
+		return fmt.Errorf("%w: %s: expected %s, got %s", ErrChecksumMismatch, name, expected, actual)
+	}
+	return nil
+}
+
+// unescapeManifestName reverses the coreutils escaping of "\\", "\n" and "\r" in file names.
+func unescapeManifestName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+1 < len(name) {
+			switch name[i+1] {
+			case '\\':
+				b.WriteByte('\\')
+				i++
+				continue
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case 'r':
+				b.WriteByte('\r')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(name[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package utilities_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func TestChecksum_KnownDigests(t *testing.T) {
+	want := map[utilities.HashAlgorithm]string{
+		utilities.HashSHA256:  "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		utilities.HashSHA512:  "9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043",
+		utilities.HashBLAKE2b: "e4cfa39a3d37be31c59609e807970799caa68a19bfaa15135f165085e01d41a65ba1e1b146aeb6bd0092b49eac214c103ccfa3a365954bbbe52f74a2b3620c94",
+		utilities.HashCRC32:   "3610a686",
+	}
+	sums, err := utilities.MultiChecksum(strings.NewReader("hello"), 5, nil, utilities.HashSHA256, utilities.HashSHA512, utilities.HashBLAKE2b, utilities.HashCRC32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for algo, digest := range want {
+		if sums[algo] != digest {
+			t.Errorf("%s=%s want %s", algo, sums[algo], digest)
+		}
+	}
+	if _, err := utilities.Checksum(strings.NewReader(""), "md4", nil); err == nil {
+		t.Error("expected error for unsupported algorithm")
+	}
+}
+
+func TestChecksumFile_ProgressAndVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.bin")
+	data := bytes.Repeat([]byte("0123456789abcdef"), 200_000) // 3.2 MB, several buffer reads
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var calls int
+	var last, total int64
+	digest, err := utilities.ChecksumFile(path, utilities.HashSHA256, func(done, tot int64) {
+		calls++
+		last, total = done, tot
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls < 3 || last != int64(len(data)) || total != int64(len(data)) {
+		t.Errorf("progress calls=%d last=%d total=%d", calls, last, total)
+	}
+	if err := utilities.VerifyChecksumFile(path, utilities.HashSHA256, strings.ToUpper(digest), nil); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+	err = utilities.VerifyChecksum(bytes.NewReader(data[1:]), utilities.HashSHA256, digest, nil)
+	if !errors.Is(err, utilities.ErrChecksumMismatch) {
+		t.Errorf("expected mismatch, got %v", err)
+	}
+}
+
+func TestChecksumManifest_RoundTripAndVerify(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{"a.txt": "alpha", "sub/b.txt": "bravo", "odd\\name.txt": "charlie"}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		_ = os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = os.Symlink("a.txt", filepath.Join(root, "link"))
+	manifest := filepath.Join(root, "SHA256SUMS")
+	if err := utilities.CreateChecksumManifest(root, manifest, utilities.HashSHA256, nil); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := utilities.ReadChecksumManifest(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].Path != "a.txt" || entries[1].Path != "odd\\name.txt" || entries[2].Path != "sub/b.txt" {
+		t.Fatalf("entries=%v", entries)
+	}
+	if failures, err := utilities.VerifyChecksumManifest(manifest, "", utilities.HashSHA256, nil); err != nil || failures != nil {
+		t.Fatalf("verify clean tree: %v %v", failures, err)
+	}
+	if sha256sum, err := exec.LookPath("sha256sum"); err == nil {
+		cmd := exec.Command(sha256sum, "-c", "--quiet", "SHA256SUMS")
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("sha256sum -c: %v\n%s", err, out)
+		}
+	}
+
+	_ = os.WriteFile(filepath.Join(root, "a.txt"), []byte("tampered"), 0644)
+	_ = os.Remove(filepath.Join(root, "sub", "b.txt"))
+	entries = append(entries, utilities.ChecksumEntry{Digest: entries[0].Digest, Path: "../outside"})
+	failures, err := utilities.VerifyChecksumTree(root, entries, utilities.HashSHA256, nil)
+	if !errors.Is(err, utilities.ErrChecksumMismatch) || len(failures) != 3 {
+		t.Fatalf("failures=%v err=%v", failures, err)
+	}
+	if !errors.Is(failures[0].Err, utilities.ErrChecksumMismatch) || failures[0].Actual == "" {
+		t.Errorf("tampered: %+v", failures[0])
+	}
+	if !errors.Is(failures[1].Err, fs.ErrNotExist) {
+		t.Errorf("missing: %+v", failures[1])
+	}
+	if !errors.Is(failures[2].Err, utilities.ErrPathEscapes) {
+		t.Errorf("escape: %+v", failures[2])
+	}
+}
+
+func TestReadChecksumManifest_Formats(t *testing.T) {
+	in := "# comment\n" +
+		"AABB *bin.dat\n" +
+		"\\ccdd  line\\nbreak\n\n"
+	entries, err := utilities.ReadChecksumManifest(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0] != (utilities.ChecksumEntry{Digest: "aabb", Path: "bin.dat"}) || entries[1].Path != "line\nbreak" {
+		t.Errorf("entries=%q", entries)
+	}
+	var buf bytes.Buffer
+	_ = utilities.WriteChecksumManifest(&buf, entries[1:])
+	if buf.String() != "\\ccdd  line\\nbreak\n" {
+		t.Errorf("written=%q", buf.String())
+	}
+	if _, err := utilities.ReadChecksumManifest(strings.NewReader("zz  file\n")); err == nil {
+		t.Error("expected error for non-hex digest")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"gorm.io/datatypes"
 )
@@ -11,7 +12,10 @@ import (
 type (
 	// DbDSNConfig contains the fields used to construct a database DSN string.
 	// Fields map to host, port, database name, user, password, SSL mode and timezone.
+	// Driver selects the DSN flavor produced by DbDSNFor; the remaining optional fields are
+	// only emitted by the drivers that understand them.
 	DbDSNConfig struct {
+		Driver   DbDriver
 		Server   string
 		Port     int
 		Name     string
@@ -19,14 +23,22 @@ type (
 		Password string
 		SSLMode  bool
 		TimeZone string
+		// SSL selects an explicit SSL mode and takes precedence over the SSLMode flag when set.
+		SSL             SSLMode
+		SSLRootCert     string
+		ApplicationName string
+		ConnectTimeout  time.Duration
+		// Params holds extra driver-specific options appended to the DSN.
+		Params map[string]string
 	}
 )
 
-// DbDSN generates a database connection string (DSN) based on the provided configuration structure, including server, port, database name, user, password, SSL mode, and timezone. The SSL mode defaults to "enable" or "disable" based on the cfg.SSLMode flag.
+// DbDSN generates a database connection string (DSN) based on the provided configuration structure, including server, port, database name, user, password, SSL mode, and timezone. The SSL mode is "require" or "disable" based on the cfg.SSLMode flag.
+// DbDSN is kept for compatibility with existing callers; new code should use DbDSNFor or DbPostgresDSN, which honor cfg.SSL and quote values correctly.
 func DbDSN(cfg DbDSNConfig) string {
 	var sm string
 	if cfg.SSLMode {
-		sm = "enable"
+		sm = "require"
 	} else {
 		sm = "disable"
 	}
@@ -12,7 +12,7 @@ func (m myValuer) Value() (driver.Value, error) { return int(m), nil }
 
 func TestDbDSN(t *testing.T) {
 	cfg := utilities.DbDSNConfig{Server: "localhost", Name: "testdb", SSLMode: true}
-	if got, want := utilities.DbDSN(cfg), "host=localhost dbname=testdb sslmode=enable"; got != want {
+	if got, want := utilities.DbDSN(cfg), "host=localhost dbname=testdb sslmode=require"; got != want {
 		t.Fatalf("DbDSN basic mismatch: got %q want %q", got, want)
 	}
 
@@ -0,0 +1,203 @@
+// Package dbtest provides per-test databases for code built on the utilities database helpers:
+// an in-process SQLite database, or a throwaway PostgreSQL server when local binaries exist.
+// Each database is migrated, and the test receives a GORM transaction that is rolled back when
+// the test finishes.
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dan-sherwin/go-utilities"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type (
+	// Options configures a test database.
+	Options struct {
+		// Migrations holds "<version>_<name>.up.sql" files applied with utilities.Migrator; none when nil.
+		Migrations fs.FS
+		// MigrationsDir is the directory within Migrations; "." when empty.
+		MigrationsDir string
+		// Postgres makes Open use a local PostgreSQL server when one is available.
+		Postgres bool
+		// GormConfig is passed to gorm.Open; logging is silenced when nil.
+		GormConfig *gorm.Config
+	}
+
+	// DB is a migrated test database.
+	DB struct {
+		// Config connects to the database, e.g. for code under test that opens its own connection.
+		// Such connections do not see the uncommitted changes made through Tx.
+		Config utilities.DbDSNConfig
+		// Conn is the connection used for setup; changes made here are committed.
+		Conn *gorm.DB
+		// Tx is a transaction rolled back when the test ends.
+		Tx *gorm.DB
+	}
+)
+
+// Open returns a test database: PostgreSQL when opts.Postgres is set and PostgresAvailable reports
+// true, otherwise SQLite.
+func Open(t testing.TB, opts Options) *DB {
+	t.Helper()
+	if opts.Postgres {
+		if _, ok := PostgresAvailable(); ok {
+			return Postgres(t, opts)
+		}
+	}
+	return SQLite(t, opts)
+}
+
+// SQLite returns a migrated SQLite database stored in the test's temporary directory.
+func SQLite(t testing.TB, opts Options) *DB {
+	t.Helper()
+	cfg := utilities.DbDSNConfig{
+		Driver: utilities.DbDriverSQLite,
+		Name:   filepath.Join(t.TempDir(), "test.db"),
+		Params: map[string]string{"_pragma": "busy_timeout(5000)"},
+	}
+	return setup(t, cfg, opts, sqlite.Open)
+}
+
+// Postgres starts a PostgreSQL server from local binaries in a temporary directory, stops it when
+// the test ends and returns a migrated database on it. The test is skipped when no usable binaries
+// are found (see PostgresAvailable).
+func Postgres(t testing.TB, opts Options) *DB {
+	t.Helper()
+	bin, ok := PostgresAvailable()
+	if !ok {
+		t.Skip("dbtest: PostgreSQL binaries (initdb, pg_ctl) not available")
+	}
+	cfg, err := startPostgres(t, bin)
+	if err != nil {
+		t.Fatalf("dbtest: %v", err)
+	}
+	return setup(t, cfg, opts, nil)
+}
+
+// PostgresAvailable returns the directory holding initdb and pg_ctl. PG_BIN is checked first, then
+// PATH and the usual Debian/RHEL install locations. initdb refuses to run as root, so it always
+// reports false for root.
+func PostgresAvailable() (string, bool) {
+	if os.Geteuid() == 0 {
+		return "", false
+	}
+	candidates := []string{os.Getenv("PG_BIN")}
+	if p, err := exec.LookPath("initdb"); err == nil {
+		candidates = append(candidates, filepath.Dir(p))
+	}
+	for _, pattern := range []string{"/usr/lib/postgresql/*/bin", "/usr/pgsql-*/bin", "/usr/local/pgsql/bin"} {
+		matches, _ := filepath.Glob(pattern)
+		// prefer the newest version
+		for i := len(matches) - 1; i >= 0; i-- {
+			candidates = append(candidates, matches[i])
+		}
+	}
+	for _, dir := range candidates {
+		if dir == "" {
+			continue
+		}
+		if isExecutable(filepath.Join(dir, "initdb")) && isExecutable(filepath.Join(dir, "pg_ctl")) {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// setup opens cfg, applies migrations and begins the per-test transaction.
+func setup(t testing.TB, cfg utilities.DbDSNConfig, opts Options, dialector func(string) gorm.Dialector) *DB {
+	t.Helper()
+	gormConfig := opts.GormConfig
+	if gormConfig == nil {
+		gormConfig = &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+	}
+	ctx := context.Background()
+	conn, err := utilities.OpenGormDB(ctx, cfg, utilities.DbOpenOptions{Dialector: dialector, GormConfig: gormConfig, Attempts: 1})
+	if err != nil {
+		t.Fatalf("dbtest: %v", err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatalf("dbtest: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	if opts.Migrations != nil {
+		m := utilities.NewMigrator(sqlDB, cfg.Driver, opts.Migrations)
+		if opts.MigrationsDir != "" {
+			m.Dir = opts.MigrationsDir
+		}
+		if _, err := m.Up(ctx); err != nil {
+			t.Fatalf("dbtest: failed to migrate: %v", err)
+		}
+	}
+
+	tx := conn.Begin()
+	if tx.Error != nil {
+		t.Fatalf("dbtest: failed to begin transaction: %v", tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	return &DB{Config: cfg, Conn: conn, Tx: tx}
+}
+
+// startPostgres initializes a cluster in a temporary directory and starts it on a free local port.
+func startPostgres(t testing.TB, bin string) (utilities.DbDSNConfig, error) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data")
+	initdb := exec.Command(filepath.Join(bin, "initdb"), "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync")
+	if out, err := initdb.CombinedOutput(); err != nil {
+		return utilities.DbDSNConfig{}, fmt.Errorf("initdb failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	port, err := freePort()
+	if err != nil {
+		return utilities.DbDSNConfig{}, err
+	}
+	pgCtl := filepath.Join(bin, "pg_ctl")
+	serverOpts := fmt.Sprintf("-F -p %d -k %s -c listen_addresses=127.0.0.1", port, dir)
+	start := exec.Command(pgCtl, "-D", data, "-o", serverOpts, "-l", filepath.Join(dir, "postgres.log"), "-w", "-t", "30", "start")
+	if out, err := start.CombinedOutput(); err != nil {
+		log, _ := os.ReadFile(filepath.Join(dir, "postgres.log"))
+		return utilities.DbDSNConfig{}, fmt.Errorf("pg_ctl start failed: %w: %s\n%s", err, strings.TrimSpace(string(out)), log)
+	}
+	t.Cleanup(func() {
+		_ = exec.Command(pgCtl, "-D", data, "-m", "immediate", "-w", "stop").Run()
+	})
+	return utilities.DbDSNConfig{
+		Driver:         utilities.DbDriverPostgres,
+		Server:         "127.0.0.1",
+		Port:           port,
+		Name:           "postgres",
+		User:           "postgres",
+		SSL:            utilities.SSLDisable,
+		ConnectTimeout: 10 * time.Second,
+	}, nil
+}
+
+// freePort asks the kernel for an unused TCP port on the loopback interface.
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free port: %w", err)
+	}
+	defer l.Close()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	return strconv.Atoi(port)
+}
+
+// isExecutable reports whether path is a regular file with an execute bit set.
+func isExecutable(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode().IsRegular() && fi.Mode()&0o111 != 0
+}
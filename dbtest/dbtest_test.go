@@ -0,0 +1,103 @@
+package dbtest_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+	"github.com/dan-sherwin/go-utilities/dbtest"
+	"github.com/glebarez/sqlite"
+	"gorm.io/datatypes"
+)
+
+type widget struct {
+	ID    int64
+	Name  string
+	Attrs datatypes.JSONMap
+}
+
+var migrations = fstest.MapFS{
+	"001_widgets.up.sql":   {Data: []byte("CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT NOT NULL, attrs JSON)")},
+	"001_widgets.down.sql": {Data: []byte("DROP TABLE widgets")},
+}
+
+func TestSQLite_MigratesAndRollsBack(t *testing.T) {
+	// Cleanups run in reverse order: the transaction is rolled back before the check below runs,
+	// and the temporary directory (created first) is removed last.
+	t.TempDir()
+	var db *dbtest.DB
+	t.Cleanup(func() {
+		other, err := utilities.OpenGormDB(context.Background(), db.Config, utilities.DbOpenOptions{Dialector: sqlite.Open, Attempts: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var count int64
+		if err := other.Model(&widget{}).Count(&count).Error; err != nil || count != 0 {
+			t.Errorf("count=%d err=%v after rollback", count, err)
+		}
+		if sqlDB, err := other.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	db = dbtest.SQLite(t, dbtest.Options{Migrations: migrations})
+	if db.Config.Driver != utilities.DbDriverSQLite || db.Config.Name == "" {
+		t.Fatalf("unexpected config %+v", db.Config)
+	}
+	attrs, err := utilities.ConvertToJSONMap(map[string]any{"color": "red"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Tx.Create(&widget{ID: 1, Name: "a", Attrs: attrs}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var got widget
+	if err := db.Tx.First(&got, 1).Error; err != nil || got.Attrs["color"] != "red" {
+		t.Fatalf("got=%+v err=%v", got, err)
+	}
+}
+
+func TestSQLite_ConfigOpensSameDatabase(t *testing.T) {
+	db := dbtest.SQLite(t, dbtest.Options{Migrations: migrations})
+	if err := db.Conn.Create(&widget{ID: 7, Name: "seed"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Tx.Create(&widget{ID: 8, Name: "pending"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	other, err := utilities.OpenGormDB(context.Background(), db.Config, utilities.DbOpenOptions{Dialector: sqlite.Open, Attempts: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := other.DB(); err == nil {
+		t.Cleanup(func() { _ = sqlDB.Close() })
+	}
+	var count int64
+	if err := other.Model(&widget{}).Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("count=%d err=%v; only the committed row should be visible", count, err)
+	}
+}
+
+func TestSQLite_IsolatedPerTest(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		t.Run("run", func(t *testing.T) {
+			db := dbtest.Open(t, dbtest.Options{Migrations: migrations})
+			var count int64
+			if err := db.Tx.Model(&widget{}).Count(&count).Error; err != nil || count != 0 {
+				t.Fatalf("count=%d err=%v", count, err)
+			}
+			if err := db.Tx.Create(&widget{ID: 1, Name: "x"}).Error; err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestPostgres(t *testing.T) {
+	db := dbtest.Postgres(t, dbtest.Options{Migrations: fstest.MapFS{
+		"001_widgets.up.sql": {Data: []byte("CREATE TABLE widgets (id BIGINT PRIMARY KEY, name TEXT NOT NULL, attrs JSONB)")},
+	}})
+	if err := db.Tx.Create(&widget{ID: 1, Name: "pg"}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
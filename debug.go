@@ -1,35 +1,100 @@
 package utilities
 
 import (
+	"context"
 	"fmt"
-	"github.com/sanity-io/litter"
-
+	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+
+	"github.com/sanity-io/litter"
 )
 
-// init initializes the default configuration for the litter package with custom options and preserves existing field exclusions.
-func init() {
-	fe := litter.Config.FieldExclusions
-	litter.Config = litter.Options{
-		Compact:                   false,
-		StripPackageNames:         false,
-		HidePrivateFields:         false,
-		HideZeroValues:            false,
-		FieldExclusions:           fe,
-		FieldFilter:               nil,
-		HomePackage:               "",
-		Separator:                 " ",
-		StrictGo:                  false,
-		DumpFunc:                  nil,
-		DisablePointerReplacement: false,
+// DebugEnvVar names the environment variable read by NewDebugger; a false value ("0", "false")
+// disables dumping and error logging. Unset or unparsable values leave debugging enabled.
+const DebugEnvVar = "GO_UTILITIES_DEBUG"
+
+// Debugger dumps values with its own litter options and logs errors as structured slog records
+// annotated with the caller's file:line. Unlike litter.Dump, it never reads or changes the global
+// litter.Config.
+type Debugger struct {
+	// Options controls litter formatting.
+	Options litter.Options
+	// Writer receives dumps; os.Stdout when nil.
+	Writer io.Writer
+	// Logger receives error records; slog.Default() when nil.
+	Logger *slog.Logger
+	// Attrs are added to every error record.
+	Attrs []slog.Attr
+	// Enabled turns dumping and error logging on or off.
+	Enabled bool
+}
+
+// DefaultDebugger is used by LitterCheckErr.
+var DefaultDebugger = NewDebugger()
+
+// NewDebugger returns a Debugger writing to stdout with litter's default formatting, enabled
+// according to DebugEnvVar. Private fields are shown, and like litter's defaults the XXX_ fields
+// generated by protoc-gen-go are excluded.
+func NewDebugger() *Debugger {
+	enabled := true
+	if v, ok := os.LookupEnv(DebugEnvVar); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			enabled = b
+		}
+	}
+	return &Debugger{Options: litter.Options{Separator: " ", FieldExclusions: regexp.MustCompile(`^(XXX_.*)$`)}, Enabled: enabled}
+}
+
+// Dump writes a litter dump of values followed by a newline to the Debugger's writer.
+func (d *Debugger) Dump(values ...any) {
+	if !d.Enabled {
+		return
 	}
+	w := d.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	_, _ = io.WriteString(w, d.Options.Sdump(values...)+"\n")
+}
+
+// Sdump returns a litter dump of values using the Debugger's options, regardless of Enabled.
+func (d *Debugger) Sdump(values ...any) string {
+	return d.Options.Sdump(values...)
+}
+
+// DebugCheckErr logs err (when non-nil) through d with the caller's location, dumps out and
+// returns it unchanged.
+func DebugCheckErr[T any](d *Debugger, out T, err error) T {
+	d.checkErr(2, out, err)
+	return out
 }
 
-// LitterCheckErr logs an error if present, outputs a debug dump of the provided value using the litter library, and then returns the value.
+// LitterCheckErr logs an error if present, outputs a debug dump of the provided value using DefaultDebugger, and then returns the value.
 func LitterCheckErr[T any](out T, err error) T {
+	DefaultDebugger.checkErr(2, out, err)
+	return out
+}
+
+// checkErr implements DebugCheckErr; skip is passed to runtime.Caller to find the call site.
+func (d *Debugger) checkErr(skip int, out any, err error) {
+	if !d.Enabled {
+		return
+	}
 	if err != nil {
-		slog.Error(fmt.Sprintf("*****DEBUG****** error encountered: %v", err))
+		logger := d.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		attrs := append([]slog.Attr{slog.Any("error", err)}, d.Attrs...)
+		if _, file, line, ok := runtime.Caller(skip); ok {
+			attrs = append(attrs, slog.String("caller", fmt.Sprintf("%s:%d", filepath.Base(file), line)))
+		}
+		logger.LogAttrs(context.Background(), slog.LevelError, "debug: error encountered", attrs...)
 	}
-	litter.Dump(out)
-	return out
+	d.Dump(out)
 }
@@ -1,9 +1,15 @@
 package utilities_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
-	utilities "github.com/dan-sherwin/go-utilities"
+	"log/slog"
+	"strings"
 	"testing"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+	"github.com/sanity-io/litter"
 )
 
 func TestLitterCheckErr(t *testing.T) {
@@ -18,3 +24,57 @@ func TestLitterCheckErr(t *testing.T) {
 		t.Fatalf("LitterCheckErr with err: unexpected return %v", got2)
 	}
 }
+
+func TestDebugger_DumpAndStructuredError(t *testing.T) {
+	var out, logs bytes.Buffer
+	d := utilities.NewDebugger()
+	d.Enabled = true
+	d.Writer = &out
+	d.Options.Compact = true
+	d.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+	d.Attrs = []slog.Attr{slog.String("component", "loader")}
+
+	got := utilities.DebugCheckErr(d, []int{1, 2}, errors.New("boom"))
+	if len(got) != 2 {
+		t.Fatalf("unexpected return %v", got)
+	}
+	if out.String() != "[]int{1,2}\n" {
+		t.Errorf("dump=%q", out.String())
+	}
+	var rec map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &rec); err != nil {
+		t.Fatalf("log record %q: %v", logs.String(), err)
+	}
+	if rec["error"] != "boom" || rec["component"] != "loader" || rec["level"] != "ERROR" {
+		t.Errorf("record=%v", rec)
+	}
+	if caller, _ := rec["caller"].(string); !strings.HasPrefix(caller, "debug_test.go:") {
+		t.Errorf("caller=%v", rec["caller"])
+	}
+	if litter.Config.Compact {
+		t.Errorf("Debugger options must not leak into litter.Config")
+	}
+
+	type message struct {
+		Name             string
+		XXX_unrecognized []byte
+	}
+	if dump := d.Sdump(message{Name: "m", XXX_unrecognized: []byte{1}}); strings.Contains(dump, "XXX_") {
+		t.Errorf("protobuf XXX_ fields not excluded: %s", dump)
+	}
+}
+
+func TestDebugger_EnvSwitch(t *testing.T) {
+	t.Setenv(utilities.DebugEnvVar, "false")
+	var out bytes.Buffer
+	d := utilities.NewDebugger()
+	d.Writer = &out
+	d.Dump("hidden")
+	if d.Enabled || out.Len() != 0 {
+		t.Errorf("debugger should be disabled by %s=false", utilities.DebugEnvVar)
+	}
+	t.Setenv(utilities.DebugEnvVar, "1")
+	if !utilities.NewDebugger().Enabled {
+		t.Errorf("debugger should be enabled by %s=1", utilities.DebugEnvVar)
+	}
+}
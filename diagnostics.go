@@ -0,0 +1,150 @@
+package utilities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"time"
+)
+
+// Diagnostics writes runtime snapshots of the process to timestamped files in Dir. Each file holds
+// the goroutine stacks, the heap profile (text form), runtime.MemStats and the GC statistics.
+type Diagnostics struct {
+	// Dir receives the files; it is created with DirCreateIfNotExists on each dump.
+	Dir string
+	// Prefix starts each file name; "diagnostics" when empty.
+	Prefix string
+}
+
+// NewDiagnostics returns a Diagnostics writing to dir.
+func NewDiagnostics(dir string) *Diagnostics {
+	return &Diagnostics{Dir: dir, Prefix: "diagnostics"}
+}
+
+// Dump writes a snapshot to "<Prefix>-<UTC timestamp>-<pid>.txt" in Dir and returns the file path.
+func (d *Diagnostics) Dump() (string, error) {
+	if err := DirCreateIfNotExists(d.Dir); err != nil {
+		return "", err
+	}
+	prefix := d.Prefix
+	if prefix == "" {
+		prefix = "diagnostics"
+	}
+	name := fmt.Sprintf("%s-%s-%d.txt", prefix, time.Now().UTC().Format("20060102T150405.000000000Z"), os.Getpid())
+	path := filepath.Join(d.Dir, name)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create diagnostics file: %w", err)
+	}
+	if err := WriteDiagnostics(f); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// HandleSignals dumps a snapshot each time one of sigs arrives (SIGQUIT and SIGUSR1 when none are
+// given; on Windows, which has neither, nothing is handled by default) until ctx is done or the
+// returned stop function is called. Results are logged with slog. Catching SIGQUIT replaces the Go
+// runtime's default stack dump and exit.
+func (d *Diagnostics) HandleSignals(ctx context.Context, sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = diagnosticsSignals
+	}
+	if len(sigs) == 0 {
+		// signal.Notify without signals would relay every signal
+		return func() {}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-ch:
+				if path, err := d.Dump(); err != nil {
+					slog.Error("failed to write diagnostics", "signal", sig.String(), "error", err)
+				} else {
+					slog.Info("diagnostics written", "signal", sig.String(), "file", path)
+				}
+			}
+		}
+	}()
+	return cancel
+}
+
+// ServeHTTP writes a snapshot to Dir and responds with the file path. With ?inline=1 the snapshot
+// is also returned as the response body. Mount it on an internal or authenticated route only.
+func (d *Diagnostics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path, err := d.Dump()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Diagnostics-File", path)
+	if r.URL.Query().Get("inline") == "1" {
+		if f, err := os.Open(path); err == nil {
+			defer f.Close()
+			_, _ = io.Copy(w, f)
+			return
+		}
+	}
+	_, _ = fmt.Fprintln(w, path)
+}
+
+// WriteDiagnostics writes goroutine stacks, the heap profile, runtime.MemStats and GC statistics
+// to w as plain-text sections.
+func WriteDiagnostics(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "time: %s\npid: %d\ngo: %s\ngoroutines: %d\n", time.Now().Format(time.RFC3339Nano), os.Getpid(), runtime.Version(), runtime.NumGoroutine()); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, "\n=== goroutines ===\n"); err != nil {
+		return err
+	}
+	if err := pprof.Lookup("goroutine").WriteTo(w, 2); err != nil {
+		return fmt.Errorf("failed to write goroutine stacks: %w", err)
+	}
+
+	if _, err := io.WriteString(w, "\n=== heap profile ===\n"); err != nil {
+		return err
+	}
+	if err := pprof.Lookup("heap").WriteTo(w, 1); err != nil {
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	if err := writeDiagnosticsJSON(w, "memstats", mem); err != nil {
+		return err
+	}
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+	return writeDiagnosticsJSON(w, "gc stats", gc)
+}
+
+// writeDiagnosticsJSON writes v as an indented JSON section titled title.
+func writeDiagnosticsJSON(w io.Writer, title string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", title, err)
+	}
+	_, err = fmt.Fprintf(w, "\n=== %s ===\n%s\n", title, data)
+	return err
+}
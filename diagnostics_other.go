@@ -0,0 +1,8 @@
+//go:build !unix
+
+package utilities
+
+import "os"
+
+// diagnosticsSignals is empty: there is no conventional dump signal on this platform.
+var diagnosticsSignals []os.Signal
@@ -0,0 +1,50 @@
+package utilities_test
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func TestDiagnostics_Dump(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "diag", "nested")
+	path, err := utilities.NewDiagnostics(dir).Dump()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(path) != dir || !strings.HasPrefix(filepath.Base(path), "diagnostics-") {
+		t.Errorf("unexpected path %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, section := range []string{"=== goroutines ===", "TestDiagnostics_Dump", "=== heap profile ===", "=== memstats ===", `"HeapAlloc"`, "=== gc stats ===", `"NumGC"`} {
+		if !strings.Contains(string(data), section) {
+			t.Errorf("dump missing %q", section)
+		}
+	}
+}
+
+func TestDiagnostics_ServeHTTP(t *testing.T) {
+	d := utilities.NewDiagnostics(t.TempDir())
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("POST", "/debug/diagnostics", nil))
+	path := strings.TrimSpace(w.Body.String())
+	if w.Code != 200 || w.Header().Get("X-Diagnostics-File") != path {
+		t.Fatalf("code=%d body=%q", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("dump file not written: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/debug/diagnostics?inline=1", nil))
+	if !strings.Contains(w.Body.String(), "=== goroutines ===") {
+		t.Errorf("inline body missing stacks")
+	}
+}
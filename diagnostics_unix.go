@@ -0,0 +1,11 @@
+//go:build unix
+
+package utilities
+
+import (
+	"os"
+	"syscall"
+)
+
+// diagnosticsSignals are the default signals for Diagnostics.HandleSignals.
+var diagnosticsSignals = []os.Signal{syscall.SIGQUIT, syscall.SIGUSR1}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package utilities_test
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func TestDiagnostics_HandleSignals(t *testing.T) {
+	dir := t.TempDir()
+	stop := utilities.NewDiagnostics(dir).HandleSignals(context.Background(), syscall.SIGUSR1)
+	defer stop()
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if entries, _ := os.ReadDir(dir); len(entries) == 1 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("no diagnostics file written after SIGUSR1")
+}
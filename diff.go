@@ -0,0 +1,235 @@
+package utilities
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// Difference is one mismatch found by Diff: the field path and both sides formatted for display.
+	// Missing map entries and slice elements are shown as "<missing>".
+	Difference struct {
+		Path  string
+		Left  string
+		Right string
+	}
+
+	// DiffReport lists the differences between two values in traversal order; it is empty when the
+	// values are deeply equal.
+	DiffReport []Difference
+
+	// diffVisit records a pointer pair already being compared, to stop on cyclic structures.
+	diffVisit struct {
+		a, b uintptr
+		typ  reflect.Type
+	}
+
+	differ struct {
+		report  DiffReport
+		visited map[diffVisit]bool
+	}
+)
+
+const diffMissing = "<missing>"
+
+// Diff walks a and b and reports every difference by field path, e.g.
+// "Orders[3].Items[0].Price: 10 != 12". Structs are compared by exported field, maps by key,
+// slices and arrays element by element, and time.Time values with Equal. Values of different
+// dynamic types are reported as a single difference.
+func Diff(a, b any) DiffReport {
+	d := &differ{visited: map[diffVisit]bool{}}
+	d.walk("", reflect.ValueOf(a), reflect.ValueOf(b))
+	return d.report
+}
+
+// String formats the difference as "path: left != right".
+func (d Difference) String() string {
+	if d.Path == "" {
+		return d.Left + " != " + d.Right
+	}
+	return d.Path + ": " + d.Left + " != " + d.Right
+}
+
+// Equal reports whether no differences were found.
+func (r DiffReport) Equal() bool {
+	return len(r) == 0
+}
+
+// String returns one difference per line.
+func (r DiffReport) String() string {
+	lines := make([]string, len(r))
+	for i, d := range r {
+		lines[i] = d.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// LogValue implements slog.LogValuer, rendering the report as a group keyed by path, so it can be
+// logged directly: slog.Warn("mismatch", "diff", Diff(want, got)).
+func (r DiffReport) LogValue() slog.Value {
+	attrs := make([]slog.Attr, len(r))
+	for i, d := range r {
+		key := d.Path
+		if key == "" {
+			key = "value"
+		}
+		attrs[i] = slog.String(key, d.Left+" != "+d.Right)
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// Print prints the report as a Path/Left/Right table to stdout using PrintStructTable.
+func (r DiffReport) Print() error {
+	return PrintStructTable([]Difference(r))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// walk compares a and b at path, appending differences to the report.
+func (d *differ) walk(path string, a, b reflect.Value) {
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() != b.IsValid() {
+			d.add(path, formatDiffValue(a), formatDiffValue(b))
+		}
+		return
+	}
+	if a.Type() != b.Type() {
+		d.add(path, fmt.Sprintf("%s(%s)", a.Type(), formatDiffValue(a)), fmt.Sprintf("%s(%s)", b.Type(), formatDiffValue(b)))
+		return
+	}
+	if a.Type() == timeType && a.CanInterface() {
+		if ta, tb := a.Interface().(time.Time), b.Interface().(time.Time); !ta.Equal(tb) {
+			d.add(path, formatDiffValue(a), formatDiffValue(b))
+		}
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.add(path, formatDiffValue(a), formatDiffValue(b))
+			}
+			return
+		}
+		if a.Pointer() == b.Pointer() {
+			return
+		}
+		visit := diffVisit{a.Pointer(), b.Pointer(), a.Type()}
+		if d.visited[visit] {
+			return
+		}
+		d.visited[visit] = true
+		d.walk(path, a.Elem(), b.Elem())
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.add(path, formatDiffValue(a), formatDiffValue(b))
+			}
+			return
+		}
+		d.walk(path, a.Elem(), b.Elem())
+	case reflect.Struct:
+		t := a.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			d.walk(joinDiffPath(path, t.Field(i).Name), a.Field(i), b.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		if a.Kind() == reflect.Slice && a.IsNil() != b.IsNil() && (a.Len() != 0 || b.Len() != 0) {
+			d.add(path, formatDiffValue(a), formatDiffValue(b))
+			return
+		}
+		n := max(a.Len(), b.Len())
+		for i := 0; i < n; i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= a.Len():
+				d.add(p, diffMissing, formatDiffValue(b.Index(i)))
+			case i >= b.Len():
+				d.add(p, formatDiffValue(a.Index(i)), diffMissing)
+			default:
+				d.walk(p, a.Index(i), b.Index(i))
+			}
+		}
+	case reflect.Map:
+		if a.IsNil() != b.IsNil() && (a.Len() != 0 || b.Len() != 0) {
+			d.add(path, formatDiffValue(a), formatDiffValue(b))
+			return
+		}
+		keys := a.MapKeys()
+		for _, k := range b.MapKeys() {
+			if !a.MapIndex(k).IsValid() {
+				keys = append(keys, k)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range keys {
+			p := fmt.Sprintf("%s[%s]", path, formatDiffValue(k))
+			av, bv := a.MapIndex(k), b.MapIndex(k)
+			switch {
+			case !av.IsValid():
+				d.add(p, diffMissing, formatDiffValue(bv))
+			case !bv.IsValid():
+				d.add(p, formatDiffValue(av), diffMissing)
+			default:
+				d.walk(p, av, bv)
+			}
+		}
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if a.Pointer() != b.Pointer() {
+			d.add(path, formatDiffValue(a), formatDiffValue(b))
+		}
+	default:
+		if !a.Equal(b) {
+			d.add(path, formatDiffValue(a), formatDiffValue(b))
+		}
+	}
+}
+
+func (d *differ) add(path, left, right string) {
+	d.report = append(d.report, Difference{Path: path, Left: left, Right: right})
+}
+
+// joinDiffPath appends a field name to path with a dot separator.
+func joinDiffPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// formatDiffValue renders v compactly: strings quoted, nil pointers and invalid values as <nil>,
+// composite values with %+v.
+func formatDiffValue(v reflect.Value) string {
+	if !v.IsValid() {
+		return "<nil>"
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("%q", v.String())
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return "<nil>"
+		}
+		if v.Kind() == reflect.Pointer {
+			return "&" + formatDiffValue(v.Elem())
+		}
+		if v.Kind() == reflect.Interface {
+			return formatDiffValue(v.Elem())
+		}
+	}
+	if v.CanInterface() {
+		if t, ok := v.Interface().(time.Time); ok {
+			return t.Format(time.RFC3339Nano)
+		}
+		return fmt.Sprintf("%+v", v.Interface())
+	}
+	return fmt.Sprintf("%+v", v)
+}
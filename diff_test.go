@@ -0,0 +1,87 @@
+package utilities_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+type diffItem struct {
+	SKU   string
+	Price int
+}
+
+type diffOrder struct {
+	ID    int
+	Items []diffItem
+	Tags  map[string]string
+	Note  *string
+	At    time.Time
+}
+
+type diffCustomer struct {
+	Name   string
+	Orders []diffOrder
+	secret int
+}
+
+func TestDiff_ReportsFieldPaths(t *testing.T) {
+	at := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	left := diffCustomer{Name: "ada", secret: 1, Orders: []diffOrder{
+		{ID: 1, Items: []diffItem{{SKU: "a", Price: 10}}, Tags: map[string]string{"k": "v"}, At: at},
+	}}
+	right := diffCustomer{Name: "ada", secret: 2, Orders: []diffOrder{
+		{ID: 1, Items: []diffItem{{SKU: "a", Price: 12}, {SKU: "b", Price: 1}}, Tags: map[string]string{"k": "w", "n": "x"}, Note: utilities.Ptr("hi"), At: at.In(time.FixedZone("X", 3600))},
+	}}
+
+	report := utilities.Diff(left, right)
+	want := []string{
+		`Orders[0].Items[0].Price: 10 != 12`,
+		`Orders[0].Items[1]: <missing> != {SKU:b Price:1}`,
+		`Orders[0].Tags["k"]: "v" != "w"`,
+		`Orders[0].Tags["n"]: <missing> != "x"`,
+		`Orders[0].Note: <nil> != &"hi"`,
+	}
+	if got := report.String(); got != strings.Join(want, "\n") {
+		t.Errorf("report:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+	if report.Equal() || !utilities.Diff(left, left).Equal() {
+		t.Errorf("Equal mismatch")
+	}
+}
+
+func TestDiff_TypesAndScalars(t *testing.T) {
+	if got := utilities.Diff(1, 2).String(); got != "1 != 2" {
+		t.Errorf("scalar diff=%q", got)
+	}
+	if got := utilities.Diff(any(1), any("1")).String(); got != `int(1) != string("1")` {
+		t.Errorf("type diff=%q", got)
+	}
+	if !utilities.Diff([]int(nil), []int{}).Equal() {
+		t.Errorf("nil and empty slices should compare equal")
+	}
+	type node struct {
+		Val  int
+		Next *node
+	}
+	a := &node{Val: 1}
+	a.Next = a
+	b := &node{Val: 1}
+	b.Next = b
+	if !utilities.Diff(a, b).Equal() {
+		t.Errorf("cyclic equal values should not differ")
+	}
+}
+
+func TestDiffReport_LogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	logger.Info("mismatch", "diff", utilities.Diff(diffItem{Price: 1}, diffItem{Price: 2}))
+	if !strings.Contains(buf.String(), `diff.Price="1 != 2"`) {
+		t.Errorf("log=%q", buf.String())
+	}
+}
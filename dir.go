@@ -0,0 +1,248 @@
+package utilities
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirEnsure makes sure dir exists as a directory with exactly mode (regardless of the umask) and,
+// when uid or gid is not -1, the given ownership. Missing parents are created with mode, subject
+// to the umask.
+func DirEnsure(dir string, mode os.FileMode, uid, gid int) error {
+	fi, err := os.Stat(dir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if err := os.MkdirAll(dir, mode); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to check directory %s: %w", dir, err)
+	case !fi.IsDir():
+		return fmt.Errorf("%s exists and is not a directory", dir)
+	}
+	if err := os.Chmod(dir, mode.Perm()|mode&(fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky)); err != nil {
+		return fmt.Errorf("failed to set mode of %s: %w", dir, err)
+	}
+	if uid != -1 || gid != -1 {
+		if err := os.Chown(dir, uid, gid); err != nil {
+			return fmt.Errorf("failed to set owner of %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// TempDir creates a new temporary directory in parent (os.TempDir() when empty) using pattern as in
+// os.MkdirTemp, and returns a cleanup function that removes it with RemoveTreeSafe.
+func TempDir(parent, pattern string) (string, func() error, error) {
+	if parent == "" {
+		parent = os.TempDir()
+	}
+	dir, err := os.MkdirTemp(parent, pattern)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	return dir, func() error { return RemoveTreeSafe(dir, parent) }, nil
+}
+
+// CopyTree copies the directory tree at src to dst, preserving permissions, modification times and,
+// when running with sufficient privileges, ownership. Symlinks are recreated rather than followed.
+// dst may already exist, in which case files are overwritten; it must not lie inside src.
+func CopyTree(src, dst string) error {
+	srcAbs, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	dstAbs, err := filepath.Abs(dst)
+	if err != nil {
+		return err
+	}
+	if pathWithin(dstAbs, srcAbs) {
+		return fmt.Errorf("cannot copy %s into itself (%s)", src, dst)
+	}
+	rootInfo, err := os.Stat(srcAbs)
+	if err != nil {
+		return err
+	}
+	if !rootInfo.IsDir() {
+		return fmt.Errorf("%s is not a directory", src)
+	}
+
+	var dirs []string
+	err = filepath.WalkDir(srcAbs, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcAbs, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dstAbs, rel)
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			// writable while copying; the final mode is applied after the contents
+			if err := os.MkdirAll(target, 0700); err != nil {
+				return err
+			}
+			dirs = append(dirs, path)
+			return nil
+		case fi.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+			copyOwner(target, fi, true)
+			return nil
+		case fi.Mode().IsRegular():
+			return copyFileWithMetadata(path, target, fi)
+		default:
+			return fmt.Errorf("cannot copy special file %s", path)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy %s to %s: %w", src, dst, err)
+	}
+	// apply directory metadata deepest first so setting times is not undone by later writes
+	for i := len(dirs) - 1; i >= 0; i-- {
+		fi, err := os.Stat(dirs[i])
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(srcAbs, dirs[i])
+		if err := applyMetadata(filepath.Join(dstAbs, rel), fi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MoveTree moves src to dst with os.Rename, falling back to CopyTree and removal of src when they
+// are on different filesystems.
+func MoveTree(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !isCrossDevice(err) {
+		return err
+	}
+	if err := CopyTree(src, dst); err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// TreeSize returns the total size in bytes of the regular files under root. Symlinks are not followed.
+func TreeSize(root string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+			total += fi.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// RemoveTreeSafe removes path and everything below it, like os.RemoveAll, but refuses to remove
+// the filesystem root, root itself, or anything that does not resolve (after following symlinks in
+// its parent directories) to a location strictly inside root. An empty root only applies the "/" guard.
+func RemoveTreeSafe(path, root string) error {
+	target, err := resolveParent(path)
+	if err != nil {
+		return err
+	}
+	if target == string(filepath.Separator) || filepath.Dir(target) == target {
+		return fmt.Errorf("refusing to remove filesystem root %s", path)
+	}
+	if root != "" {
+		rootAbs, err := resolveParent(root)
+		if err != nil {
+			return err
+		}
+		if resolved, err := filepath.EvalSymlinks(rootAbs); err == nil {
+			rootAbs = resolved
+		}
+		if target == rootAbs || !pathWithin(target, rootAbs) {
+			return fmt.Errorf("refusing to remove %s: not inside %s", path, root)
+		}
+	}
+	return os.RemoveAll(target)
+}
+
+// resolveParent makes path absolute and resolves symlinks in its parent directories, but not in the
+// final element, so a symlink itself is removed rather than its target.
+func resolveParent(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	parent, base := filepath.Dir(abs), filepath.Base(abs)
+	if resolved, err := filepath.EvalSymlinks(parent); err == nil {
+		parent = resolved
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if abs == parent {
+		return abs, nil
+	}
+	return filepath.Join(parent, base), nil
+}
+
+// pathWithin reports whether path is root or lies below it; both must be clean absolute paths.
+func pathWithin(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// copyFileWithMetadata copies a regular file and applies fi's mode, times and ownership. An existing
+// dst is removed first, so read-only files and symlinks are replaced rather than written through.
+func copyFileWithMetadata(src, dst string, fi fs.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.Remove(dst); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return applyMetadata(dst, fi)
+}
+
+// applyMetadata sets mode, modification time and (best effort) ownership of path from fi.
+func applyMetadata(path string, fi fs.FileInfo) error {
+	copyOwner(path, fi, false)
+	if err := os.Chmod(path, fi.Mode().Perm()|fi.Mode()&(fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky)); err != nil {
+		return err
+	}
+	return os.Chtimes(path, fi.ModTime(), fi.ModTime())
+}
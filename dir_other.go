@@ -0,0 +1,13 @@
+//go:build !unix && !windows
+
+package utilities
+
+import "io/fs"
+
+// isCrossDevice is always false here, so MoveTree only renames.
+func isCrossDevice(error) bool {
+	return false
+}
+
+// copyOwner is a no-op: file ownership is not expressed as uid/gid on this platform.
+func copyOwner(string, fs.FileInfo, bool) {}
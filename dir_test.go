@@ -0,0 +1,170 @@
+package utilities_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func TestDirEnsure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "a", "b")
+	if err := utilities.DirEnsure(dir, 0750, -1, -1); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() || fi.Mode().Perm() != 0750 {
+		t.Fatalf("fi=%v err=%v", fi, err)
+	}
+	// existing directories are brought to the requested mode
+	if err := utilities.DirEnsure(dir, 0700, os.Getuid(), os.Getgid()); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Stat(dir); fi.Mode().Perm() != 0700 {
+		t.Errorf("mode=%v", fi.Mode().Perm())
+	}
+	file := filepath.Join(dir, "f")
+	_ = os.WriteFile(file, nil, 0644)
+	if err := utilities.DirEnsure(file, 0700, -1, -1); err == nil {
+		t.Errorf("expected error for a regular file")
+	}
+}
+
+func TestDirCreateIfNotExists_WrapsErrors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "f")
+	_ = os.WriteFile(file, nil, 0644)
+	err := utilities.DirCreateIfNotExists(filepath.Join(file, "sub"))
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) {
+		t.Errorf("error should wrap the underlying *fs.PathError: %v", err)
+	}
+}
+
+func TestCopyTree_PreservesMetadata(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	_ = os.MkdirAll(filepath.Join(src, "sub"), 0755)
+	_ = os.WriteFile(filepath.Join(src, "run.sh"), []byte("#!/bin/sh\n"), 0750)
+	_ = os.WriteFile(filepath.Join(src, "sub", "data"), []byte("12345"), 0600)
+	_ = os.Symlink("sub/data", filepath.Join(src, "link"))
+	old := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	_ = os.Chtimes(filepath.Join(src, "sub", "data"), old, old)
+	_ = os.Chmod(filepath.Join(src, "sub"), 0710)
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := utilities.CopyTree(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Stat(filepath.Join(dst, "run.sh")); fi.Mode().Perm() != 0750 {
+		t.Errorf("run.sh mode=%v", fi.Mode().Perm())
+	}
+	if fi, _ := os.Stat(filepath.Join(dst, "sub")); fi.Mode().Perm() != 0710 {
+		t.Errorf("sub mode=%v", fi.Mode().Perm())
+	}
+	fi, err := os.Stat(filepath.Join(dst, "sub", "data"))
+	if err != nil || !fi.ModTime().Equal(old) || fi.Mode().Perm() != 0600 {
+		t.Errorf("data fi=%v err=%v", fi, err)
+	}
+	if link, err := os.Readlink(filepath.Join(dst, "link")); err != nil || link != "sub/data" {
+		t.Errorf("link=%q err=%v", link, err)
+	}
+	if size, err := utilities.TreeSize(dst); err != nil || size != 15 {
+		t.Errorf("TreeSize=%d err=%v", size, err)
+	}
+	if err := utilities.CopyTree(src, filepath.Join(src, "sub", "inner")); err == nil {
+		t.Errorf("copying a tree into itself should fail")
+	}
+}
+
+func TestCopyTree_OverwritesReadOnlyFiles(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	_ = os.MkdirAll(src, 0755)
+	_ = os.WriteFile(filepath.Join(src, "ro.txt"), []byte("v1"), 0444)
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := utilities.CopyTree(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Chmod(filepath.Join(src, "ro.txt"), 0644)
+	_ = os.WriteFile(filepath.Join(src, "ro.txt"), []byte("v2"), 0444)
+	_ = os.Chmod(filepath.Join(src, "ro.txt"), 0444)
+	if err := utilities.CopyTree(src, dst); err != nil {
+		t.Fatalf("re-copy over read-only file: %v", err)
+	}
+	fi, err := os.Stat(filepath.Join(dst, "ro.txt"))
+	if data, _ := os.ReadFile(filepath.Join(dst, "ro.txt")); string(data) != "v2" || err != nil || fi.Mode().Perm() != 0444 {
+		t.Errorf("ro.txt=%q mode=%v err=%v", data, fi.Mode(), err)
+	}
+}
+
+func TestMoveTree(t *testing.T) {
+	base := t.TempDir()
+	src, dst := filepath.Join(base, "a"), filepath.Join(base, "b")
+	_ = os.MkdirAll(src, 0755)
+	_ = os.WriteFile(filepath.Join(src, "f"), []byte("x"), 0644)
+	if err := utilities.MoveTree(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "f")); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(src); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("source still exists: %v", err)
+	}
+}
+
+func TestRemoveTreeSafe(t *testing.T) {
+	root := t.TempDir()
+	victim := filepath.Join(root, "cache")
+	_ = os.MkdirAll(filepath.Join(victim, "x"), 0755)
+	outside := t.TempDir()
+	_ = os.Symlink(outside, filepath.Join(root, "escape"))
+
+	if err := utilities.RemoveTreeSafe("/", ""); err == nil {
+		t.Error("removing / must be refused")
+	}
+	if err := utilities.RemoveTreeSafe(root, root); err == nil {
+		t.Error("removing the root itself must be refused")
+	}
+	if err := utilities.RemoveTreeSafe(outside, root); err == nil {
+		t.Error("removing a path outside root must be refused")
+	}
+	if err := utilities.RemoveTreeSafe(filepath.Join(root, "escape", "..", "..", "etc"), root); err == nil {
+		t.Error("removing via .. must be refused")
+	}
+	if err := utilities.RemoveTreeSafe(filepath.Join(root, "escape", "sub"), root); err == nil {
+		t.Error("removing through a symlink leading outside root must be refused")
+	}
+	if err := utilities.RemoveTreeSafe(victim, root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(victim); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("victim still exists")
+	}
+	// the symlink itself is inside root and may be removed without touching its target
+	if err := utilities.RemoveTreeSafe(filepath.Join(root, "escape"), root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("symlink target was removed: %v", err)
+	}
+}
+
+func TestTempDir(t *testing.T) {
+	parent := t.TempDir()
+	dir, cleanup, err := utilities.TempDir(parent, "work-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(dir) != parent || !strings.HasPrefix(filepath.Base(dir), "work-") {
+		t.Errorf("dir=%s", dir)
+	}
+	if err := cleanup(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("temp dir not removed")
+	}
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package utilities
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// isCrossDevice reports whether a rename failed because source and target are on different filesystems.
+func isCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
+
+// copyOwner gives path the owner of fi when permitted; unprivileged callers keep their own ownership.
+func copyOwner(path string, fi fs.FileInfo, link bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	if link {
+		_ = os.Lchown(path, int(st.Uid), int(st.Gid))
+		return
+	}
+	_ = os.Chown(path, int(st.Uid), int(st.Gid))
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package utilities
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+)
+
+// errorNotSameDevice is ERROR_NOT_SAME_DEVICE, returned by MoveFileEx across volumes.
+const errorNotSameDevice = syscall.Errno(17)
+
+// isCrossDevice reports whether a rename failed because source and target are on different volumes.
+func isCrossDevice(err error) bool {
+	return errors.Is(err, errorNotSameDevice)
+}
+
+// copyOwner is a no-op: file ownership is not expressed as uid/gid on Windows.
+func copyOwner(string, fs.FileInfo, bool) {}
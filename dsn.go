@@ -0,0 +1,298 @@
+package utilities
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// DbDriver identifies the database driver a DSN is built for.
+	DbDriver string
+
+	// SSLMode is a libpq-style SSL mode (disable, allow, prefer, require, verify-ca, verify-full).
+	SSLMode string
+)
+
+const (
+	// DbDriverPostgres builds libpq key/value DSNs (the default when Driver is empty).
+	DbDriverPostgres DbDriver = "postgres"
+	// DbDriverMySQL builds go-sql-driver/mysql DSNs.
+	DbDriverMySQL DbDriver = "mysql"
+	// DbDriverSQLite builds SQLite file URIs.
+	DbDriverSQLite DbDriver = "sqlite"
+	// DbDriverSQLServer builds sqlserver:// URLs.
+	DbDriverSQLServer DbDriver = "sqlserver"
+)
+
+const (
+	// SSLDisable disables SSL.
+	SSLDisable SSLMode = "disable"
+	// SSLAllow tries a non-SSL connection first and falls back to SSL.
+	SSLAllow SSLMode = "allow"
+	// SSLPrefer tries an SSL connection first and falls back to non-SSL.
+	SSLPrefer SSLMode = "prefer"
+	// SSLRequire requires SSL without verifying the server certificate.
+	SSLRequire SSLMode = "require"
+	// SSLVerifyCA requires SSL and verifies the server certificate against a trusted CA.
+	SSLVerifyCA SSLMode = "verify-ca"
+	// SSLVerifyFull requires SSL and verifies both the certificate and the server host name.
+	SSLVerifyFull SSLMode = "verify-full"
+)
+
+// Valid reports whether m is one of the SSL modes understood by libpq.
+func (m SSLMode) Valid() bool {
+	switch m {
+	case SSLDisable, SSLAllow, SSLPrefer, SSLRequire, SSLVerifyCA, SSLVerifyFull:
+		return true
+	}
+	return false
+}
+
+// EffectiveSSLMode returns cfg.SSL when set; otherwise it maps the legacy SSLMode flag to "require" or "disable".
+func (cfg DbDSNConfig) EffectiveSSLMode() SSLMode {
+	if cfg.SSL != "" {
+		return cfg.SSL
+	}
+	if cfg.SSLMode {
+		return SSLRequire
+	}
+	return SSLDisable
+}
+
+// DbDSNFor builds a DSN for cfg.Driver, defaulting to the Postgres key/value form when Driver is empty.
+// Returns an error for an unknown driver or an invalid SSL mode.
+func DbDSNFor(cfg DbDSNConfig) (string, error) {
+	if !cfg.EffectiveSSLMode().Valid() {
+		return "", fmt.Errorf("invalid sslmode %q", cfg.SSL)
+	}
+	switch cfg.Driver {
+	case "", DbDriverPostgres:
+		return DbPostgresDSN(cfg), nil
+	case DbDriverMySQL:
+		return DbMySQLDSN(cfg), nil
+	case DbDriverSQLite:
+		return DbSQLiteDSN(cfg), nil
+	case DbDriverSQLServer:
+		return DbSQLServerDSN(cfg), nil
+	default:
+		return "", fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+}
+
+// DbPostgresDSN builds a libpq key/value connection string. Values containing spaces, quotes or
+// backslashes (and empty values) are single-quoted and escaped as libpq requires.
+func DbPostgresDSN(cfg DbDSNConfig) string {
+	var parts []string
+	add := func(key, value string) {
+		parts = append(parts, key+"="+pgQuoteValue(value))
+	}
+	if cfg.Server != "" {
+		add("host", cfg.Server)
+	}
+	if cfg.Port != 0 {
+		add("port", strconv.Itoa(cfg.Port))
+	}
+	if cfg.Name != "" {
+		add("dbname", cfg.Name)
+	}
+	if cfg.User != "" {
+		add("user", cfg.User)
+	}
+	if cfg.Password != "" {
+		add("password", cfg.Password)
+	}
+	add("sslmode", string(cfg.EffectiveSSLMode()))
+	for _, kv := range pgOptionalParams(cfg) {
+		add(kv[0], kv[1])
+	}
+	return strings.Join(parts, " ")
+}
+
+// DbPostgresURL builds a postgres:// connection URL with user info, host, database and query options percent-encoded.
+func DbPostgresURL(cfg DbDSNConfig) string {
+	u := url.URL{Scheme: "postgres", Host: dsnHostPort(cfg.Server, cfg.Port)}
+	if cfg.User != "" {
+		if cfg.Password != "" {
+			u.User = url.UserPassword(cfg.User, cfg.Password)
+		} else {
+			u.User = url.User(cfg.User)
+		}
+	}
+	if cfg.Name != "" {
+		u.Path = "/" + cfg.Name
+	}
+	q := url.Values{}
+	q.Set("sslmode", string(cfg.EffectiveSSLMode()))
+	for _, kv := range pgOptionalParams(cfg) {
+		q.Set(kv[0], kv[1])
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// DbMySQLDSN builds a go-sql-driver/mysql DSN of the form user:password@tcp(host:port)/dbname?params.
+// The database name is path-escaped and parameter values are query-escaped, matching the driver's parser.
+func DbMySQLDSN(cfg DbDSNConfig) string {
+	var b strings.Builder
+	if cfg.User != "" || cfg.Password != "" {
+		b.WriteString(cfg.User)
+		if cfg.Password != "" {
+			b.WriteString(":" + cfg.Password)
+		}
+		b.WriteString("@")
+	}
+	if cfg.Server != "" {
+		port := cfg.Port
+		if port == 0 {
+			port = 3306
+		}
+		b.WriteString("tcp(" + net.JoinHostPort(cfg.Server, strconv.Itoa(port)) + ")")
+	}
+	b.WriteString("/" + url.PathEscape(cfg.Name))
+
+	params := map[string]string{}
+	switch cfg.EffectiveSSLMode() {
+	case SSLAllow, SSLPrefer:
+		params["tls"] = "preferred"
+	case SSLRequire:
+		params["tls"] = "skip-verify"
+	case SSLVerifyCA, SSLVerifyFull:
+		params["tls"] = "true"
+	}
+	if cfg.TimeZone != "" {
+		params["loc"] = cfg.TimeZone
+	}
+	if cfg.ConnectTimeout > 0 {
+		params["timeout"] = cfg.ConnectTimeout.String()
+	}
+	for k, v := range cfg.Params {
+		params[k] = v
+	}
+	if len(params) > 0 {
+		b.WriteString("?" + joinSortedParams(params, url.QueryEscape))
+	}
+	return b.String()
+}
+
+// DbSQLiteDSN builds a SQLite file URI from cfg.Name (the database file path) and cfg.Params.
+// The special name ":memory:" yields an in-memory database URI.
+func DbSQLiteDSN(cfg DbDSNConfig) string {
+	path := cfg.Name
+	if path != ":memory:" {
+		path = (&url.URL{Path: path}).EscapedPath()
+	}
+	dsn := "file:" + path
+	if len(cfg.Params) > 0 {
+		dsn += "?" + joinSortedParams(cfg.Params, url.QueryEscape)
+	}
+	return dsn
+}
+
+// DbSQLServerDSN builds a sqlserver:// URL understood by the Microsoft SQL Server driver.
+func DbSQLServerDSN(cfg DbDSNConfig) string {
+	u := url.URL{Scheme: "sqlserver", Host: dsnHostPort(cfg.Server, cfg.Port)}
+	if cfg.User != "" {
+		u.User = url.UserPassword(cfg.User, cfg.Password)
+	}
+	q := url.Values{}
+	if cfg.Name != "" {
+		q.Set("database", cfg.Name)
+	}
+	switch cfg.EffectiveSSLMode() {
+	case SSLDisable:
+		q.Set("encrypt", "disable")
+	case SSLRequire:
+		q.Set("encrypt", "true")
+		q.Set("TrustServerCertificate", "true")
+	case SSLVerifyCA, SSLVerifyFull:
+		q.Set("encrypt", "true")
+	}
+	if cfg.SSLRootCert != "" {
+		q.Set("certificate", cfg.SSLRootCert)
+	}
+	if cfg.ApplicationName != "" {
+		q.Set("app name", cfg.ApplicationName)
+	}
+	if cfg.ConnectTimeout > 0 {
+		q.Set("connection timeout", strconv.Itoa(timeoutSeconds(cfg.ConnectTimeout)))
+	}
+	for k, v := range cfg.Params {
+		q.Set(k, v)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// pgOptionalParams returns the optional Postgres parameters in a stable order.
+func pgOptionalParams(cfg DbDSNConfig) [][2]string {
+	var out [][2]string
+	if cfg.SSLRootCert != "" {
+		out = append(out, [2]string{"sslrootcert", cfg.SSLRootCert})
+	}
+	if cfg.ApplicationName != "" {
+		out = append(out, [2]string{"application_name", cfg.ApplicationName})
+	}
+	if cfg.ConnectTimeout > 0 {
+		out = append(out, [2]string{"connect_timeout", strconv.Itoa(timeoutSeconds(cfg.ConnectTimeout))})
+	}
+	if cfg.TimeZone != "" {
+		out = append(out, [2]string{"TimeZone", cfg.TimeZone})
+	}
+	keys := make([]string, 0, len(cfg.Params))
+	for k := range cfg.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		out = append(out, [2]string{k, cfg.Params[k]})
+	}
+	return out
+}
+
+// pgQuoteValue quotes a libpq key/value parameter when it is empty or contains whitespace, quotes or backslashes.
+func pgQuoteValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, " \t\n\r\f\v'\\") {
+		return v
+	}
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(v) + "'"
+}
+
+// dsnHostPort joins host and port, bracketing IPv6 literals; a zero port is omitted.
+func dsnHostPort(host string, port int) string {
+	if port == 0 {
+		if strings.Contains(host, ":") {
+			return "[" + host + "]"
+		}
+		return host
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// joinSortedParams renders params as k=v pairs joined by "&" in key order, escaping values with escape.
+func joinSortedParams(params map[string]string, escape func(string) string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+escape(params[k]))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// timeoutSeconds rounds d up to whole seconds, with a minimum of one second.
+func timeoutSeconds(d time.Duration) int {
+	s := int((d + time.Second - 1) / time.Second)
+	if s < 1 {
+		s = 1
+	}
+	return s
+}
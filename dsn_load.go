@@ -0,0 +1,271 @@
+package utilities
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInsecurePgPass is returned by PgPassPassword when the password file is readable by group or others.
+var ErrInsecurePgPass = errors.New("password file has group or world access")
+
+// DbDSNConfigFromEnv builds a DbDSNConfig from the standard libpq environment variables
+// (PGHOST, PGPORT, PGDATABASE, PGUSER, PGPASSWORD, PGSSLMODE, PGSSLROOTCERT, PGAPPNAME,
+// PGCONNECT_TIMEOUT, PGTZ). When DATABASE_URL is set it is parsed with ParseDbDSN and its
+// non-empty fields take precedence. If no password is found, the password file named by
+// PGPASSFILE (or ~/.pgpass) is consulted; like libpq, a missing file or one with group or world
+// access is skipped, the latter with a warning logged via slog. The result is validated before it is returned.
+func DbDSNConfigFromEnv() (DbDSNConfig, error) {
+	cfg, err := dbDSNConfigFromLookup(os.LookupEnv, map[string]string{
+		"host":             "PGHOST",
+		"port":             "PGPORT",
+		"name":             "PGDATABASE",
+		"user":             "PGUSER",
+		"password":         "PGPASSWORD",
+		"sslmode":          "PGSSLMODE",
+		"sslrootcert":      "PGSSLROOTCERT",
+		"application_name": "PGAPPNAME",
+		"connect_timeout":  "PGCONNECT_TIMEOUT",
+		"timezone":         "PGTZ",
+		"url":              "DATABASE_URL",
+	})
+	if err != nil {
+		return DbDSNConfig{}, err
+	}
+	if cfg.Password == "" && (cfg.Driver == "" || cfg.Driver == DbDriverPostgres) {
+		path := os.Getenv("PGPASSFILE")
+		if path == "" {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, ".pgpass")
+			}
+		}
+		if path != "" {
+			password, found, err := PgPassPassword(path, cfg)
+			if errors.Is(err, ErrInsecurePgPass) {
+				slog.Warn("ignoring password file", "file", path, "error", err)
+			} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return DbDSNConfig{}, err
+			}
+			if found {
+				cfg.Password = password
+			}
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// DbDSNConfigFromEnvPrefix builds a DbDSNConfig from a prefixed environment namespace, e.g. with
+// prefix "BILLING_DB": BILLING_DB_URL, BILLING_DB_DRIVER, BILLING_DB_HOST, BILLING_DB_PORT,
+// BILLING_DB_NAME, BILLING_DB_USER, BILLING_DB_PASSWORD, BILLING_DB_SSLMODE, BILLING_DB_SSLROOTCERT,
+// BILLING_DB_APPLICATION_NAME, BILLING_DB_CONNECT_TIMEOUT and BILLING_DB_TIMEZONE.
+// The result is validated before it is returned.
+func DbDSNConfigFromEnvPrefix(prefix string) (DbDSNConfig, error) {
+	prefix = strings.TrimSuffix(prefix, "_") + "_"
+	names := map[string]string{}
+	for _, key := range []string{"url", "driver", "host", "port", "name", "user", "password", "sslmode", "sslrootcert", "application_name", "connect_timeout", "timezone"} {
+		names[key] = prefix + strings.ToUpper(key)
+	}
+	cfg, err := dbDSNConfigFromLookup(os.LookupEnv, names)
+	if err != nil {
+		return DbDSNConfig{}, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// DbDSNConfigFromJSONFile loads a DbDSNConfig from a JSON file using FromJSONFile and validates it.
+// Keys are the DbDSNConfig field names (matched case-insensitively); ConnectTimeout is in nanoseconds.
+func DbDSNConfigFromJSONFile(filename string) (DbDSNConfig, error) {
+	var cfg DbDSNConfig
+	if err := FromJSONFile(filename, &cfg); err != nil {
+		return DbDSNConfig{}, fmt.Errorf("failed to load database config from %s: %w", filename, err)
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate checks that cfg names a supported driver and SSL mode, has a valid port, and sets the
+// fields the driver requires: a database name for every driver, plus a server for MySQL and SQL
+// Server. Postgres may leave Server empty; like libpq (and PgPassPassword) it then connects to the
+// local socket or localhost.
+func (cfg DbDSNConfig) Validate() error {
+	var missing []string
+	switch cfg.Driver {
+	case "", DbDriverPostgres, DbDriverSQLite:
+	case DbDriverMySQL, DbDriverSQLServer:
+		if cfg.Server == "" {
+			missing = append(missing, "Server")
+		}
+	default:
+		return fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+	if cfg.Name == "" {
+		missing = append(missing, "Name")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required database config fields: %s", strings.Join(missing, ", "))
+	}
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		return fmt.Errorf("invalid database port %d", cfg.Port)
+	}
+	if !cfg.EffectiveSSLMode().Valid() {
+		return fmt.Errorf("invalid sslmode %q", cfg.SSL)
+	}
+	return nil
+}
+
+// PgPassPassword looks up the password for cfg in a libpq password file (hostname:port:database:username:password).
+// Fields may be "*" to match anything, and "\:" / "\\" escape literal colons and backslashes. An empty
+// Server matches "localhost" and a zero Port matches 5432. Like libpq, files readable by group or
+// others are rejected with ErrInsecurePgPass. found is false when no line matches.
+func PgPassPassword(path string, cfg DbDSNConfig) (password string, found bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer func() { _ = f.Close() }()
+	if st, err := f.Stat(); err != nil {
+		return "", false, err
+	} else if st.Mode().Perm()&0o077 != 0 {
+		return "", false, fmt.Errorf("%w: %s; permissions should be u=rw (0600) or less", ErrInsecurePgPass, path)
+	}
+
+	host := cfg.Server
+	if host == "" {
+		host = "localhost"
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 5432
+	}
+	want := []string{host, strconv.Itoa(port), cfg.Name, cfg.User}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := splitPgPassLine(line)
+		if len(fields) != 5 {
+			continue
+		}
+		matched := true
+		for i, w := range want {
+			if fields[i] != "*" && fields[i] != w {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return fields[4], true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", false, fmt.Errorf("failed to read password file %s: %w", path, err)
+	}
+	return "", false, nil
+}
+
+// splitPgPassLine splits a password file line on unescaped colons, unescaping "\:" and "\\".
+func splitPgPassLine(line string) []string {
+	var fields []string
+	var cur strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line):
+			i++
+			cur.WriteByte(line[i])
+		case c == ':' && len(fields) < 4:
+			fields = append(fields, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	return append(fields, cur.String())
+}
+
+// dbDSNConfigFromLookup assembles a config from environment variables named by names, keyed by
+// logical setting. A "url" setting is parsed with ParseDbDSN and overrides the individual variables.
+func dbDSNConfigFromLookup(lookup func(string) (string, bool), names map[string]string) (DbDSNConfig, error) {
+	get := func(key string) string {
+		if name, ok := names[key]; ok {
+			if v, ok := lookup(name); ok {
+				return strings.TrimSpace(v)
+			}
+		}
+		return ""
+	}
+
+	var cfg DbDSNConfig
+	cfg.Driver = DbDriver(get("driver"))
+	cfg.Server = get("host")
+	cfg.Name = get("name")
+	cfg.User = get("user")
+	cfg.Password = get("password")
+	cfg.SSLRootCert = get("sslrootcert")
+	cfg.ApplicationName = get("application_name")
+	cfg.TimeZone = get("timezone")
+	if v := get("port"); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return DbDSNConfig{}, fmt.Errorf("invalid %s %q: %w", names["port"], v, err)
+		}
+		cfg.Port = port
+	}
+	if v := get("sslmode"); v != "" {
+		if err := cfg.setSSLMode(SSLMode(v)); err != nil {
+			return DbDSNConfig{}, fmt.Errorf("invalid %s: %w", names["sslmode"], err)
+		}
+	}
+	if v := get("connect_timeout"); v != "" {
+		d, err := parseTimeoutSetting(v)
+		if err != nil {
+			return DbDSNConfig{}, fmt.Errorf("invalid %s %q: %w", names["connect_timeout"], v, err)
+		}
+		cfg.ConnectTimeout = d
+	}
+
+	if v := get("url"); v != "" {
+		fromURL, err := ParseDbDSN(v)
+		if err != nil {
+			return DbDSNConfig{}, fmt.Errorf("invalid %s: %w", names["url"], err)
+		}
+		cfg = mergeDbDSNConfig(cfg, fromURL)
+	}
+	return cfg, nil
+}
+
+// parseTimeoutSetting accepts either whole seconds ("10") or a Go duration ("1m30s").
+func parseTimeoutSetting(v string) (time.Duration, error) {
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, nil
+	}
+	return time.ParseDuration(v)
+}
+
+// mergeDbDSNConfig returns base with every non-zero field of override applied on top.
+func mergeDbDSNConfig(base, override DbDSNConfig) DbDSNConfig {
+	CopyIfNotZero(override.Driver, &base.Driver)
+	CopyIfNotZero(override.Server, &base.Server)
+	CopyIfNotZero(override.Port, &base.Port)
+	CopyIfNotZero(override.Name, &base.Name)
+	CopyIfNotZero(override.User, &base.User)
+	CopyIfNotZero(override.Password, &base.Password)
+	CopyIfNotZero(override.TimeZone, &base.TimeZone)
+	CopyIfNotZero(override.SSLRootCert, &base.SSLRootCert)
+	CopyIfNotZero(override.ApplicationName, &base.ApplicationName)
+	CopyIfNotZero(override.ConnectTimeout, &base.ConnectTimeout)
+	if override.SSL != "" {
+		base.SSL, base.SSLMode = override.SSL, override.SSLMode
+	}
+	if len(override.Params) > 0 {
+		base.Params = Merge(base.Params, override.Params)
+	}
+	return base
+}
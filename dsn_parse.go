@@ -0,0 +1,424 @@
+package utilities
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redactedPassword replaces passwords in redacted DSNs, matching net/url's URL.Redacted.
+const redactedPassword = "xxxxx"
+
+// ParseDbDSN parses a DSN back into a DbDSNConfig. It accepts libpq key/value strings,
+// postgres:// and postgresql:// URLs, mysql:// URLs, go-sql-driver/mysql DSNs as built by
+// DbMySQLDSN, sqlserver:// URLs and SQLite file: URIs. Options without a dedicated DbDSNConfig
+// field are preserved in Params.
+func ParseDbDSN(dsn string) (DbDSNConfig, error) {
+	dsn = strings.TrimSpace(dsn)
+	if dsn == "" {
+		return DbDSNConfig{}, fmt.Errorf("empty DSN")
+	}
+	if strings.HasPrefix(dsn, "file:") {
+		return parseSQLiteURI(dsn)
+	}
+	if strings.Contains(dsn, "://") {
+		return parseDSNURL(dsn)
+	}
+	// like RedactDSN, anything that is not a key/value string but has a "/" is a go-sql-driver DSN
+	if _, err := splitPostgresKeyValue(dsn); err != nil && strings.Contains(dsn, "/") {
+		return parseMySQLDSN(dsn)
+	}
+	return parsePostgresKeyValue(dsn)
+}
+
+// Redacted returns the DSN for cfg with the password masked, suitable for logging.
+// Unknown drivers fall back to the Postgres key/value form.
+func (cfg DbDSNConfig) Redacted() string {
+	if cfg.Password != "" {
+		cfg.Password = redactedPassword
+	}
+	dsn, err := DbDSNFor(cfg)
+	if err != nil {
+		return DbPostgresDSN(cfg)
+	}
+	return dsn
+}
+
+// String implements fmt.Stringer by returning the redacted DSN, so configs can be logged safely.
+func (cfg DbDSNConfig) String() string {
+	return cfg.Redacted()
+}
+
+// RedactDSN masks the password in a raw DSN string without otherwise reformatting it.
+// It understands URLs, libpq key/value strings and go-sql-driver/mysql DSNs. When a string
+// cannot be parsed, everything after a "password=" key is masked; strings without a
+// recognizable password are returned unchanged.
+func RedactDSN(dsn string) string {
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return redactedPassword
+		}
+		q := u.Query()
+		for k := range q {
+			if strings.EqualFold(k, "password") {
+				q.Set(k, redactedPassword)
+				u.RawQuery = q.Encode()
+			}
+		}
+		return u.Redacted()
+	}
+	if pairs, err := splitPostgresKeyValue(dsn); err == nil {
+		parts := make([]string, 0, len(pairs))
+		for _, kv := range pairs {
+			if kv[0] == "password" {
+				kv[1] = redactedPassword
+			}
+			parts = append(parts, kv[0]+"="+pgQuoteValue(kv[1]))
+		}
+		return strings.Join(parts, " ")
+	}
+	// go-sql-driver/mysql: [user[:password]@][net[(addr)]]/dbname[?params]
+	if slash := strings.LastIndex(dsn, "/"); slash >= 0 {
+		if at := strings.LastIndex(dsn[:slash], "@"); at >= 0 {
+			if colon := strings.Index(dsn[:at], ":"); colon >= 0 {
+				return dsn[:colon+1] + redactedPassword + dsn[at:]
+			}
+		}
+	}
+	// unparseable, e.g. a key/value string with an unterminated quote: fail closed by dropping
+	// everything after a password keyword
+	if loc := dsnPasswordKeyRe.FindStringIndex(dsn); loc != nil {
+		return dsn[:loc[1]] + redactedPassword
+	}
+	return dsn
+}
+
+// dsnPasswordKeyRe finds a "password=" key in a DSN that could not be parsed.
+var dsnPasswordKeyRe = regexp.MustCompile(`(?i)\bpassword\s*=`)
+
+// parsePostgresKeyValue parses a libpq key/value connection string.
+func parsePostgresKeyValue(dsn string) (DbDSNConfig, error) {
+	pairs, err := splitPostgresKeyValue(dsn)
+	if err != nil {
+		return DbDSNConfig{}, err
+	}
+	cfg := DbDSNConfig{Driver: DbDriverPostgres}
+	for _, kv := range pairs {
+		if err := cfg.setPostgresParam(kv[0], kv[1]); err != nil {
+			return DbDSNConfig{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// splitPostgresKeyValue tokenizes a libpq key/value string into ordered key/value pairs,
+// handling single-quoted values and backslash escapes.
+func splitPostgresKeyValue(dsn string) ([][2]string, error) {
+	var pairs [][2]string
+	s := strings.TrimSpace(dsn)
+	for s != "" {
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid key/value DSN: missing \"=\" in %q", s)
+		}
+		key := strings.TrimSpace(s[:eq])
+		if !isDSNKey(key) {
+			return nil, fmt.Errorf("invalid key/value DSN: bad key %q", key)
+		}
+		s = strings.TrimLeft(s[eq+1:], " \t\n\r")
+		var val strings.Builder
+		quoted := strings.HasPrefix(s, "'")
+		if quoted {
+			s = s[1:]
+		}
+		closed := !quoted
+		i := 0
+		for ; i < len(s); i++ {
+			c := s[i]
+			if c == '\\' && i+1 < len(s) {
+				i++
+				val.WriteByte(s[i])
+				continue
+			}
+			if quoted && c == '\'' {
+				closed = true
+				i++
+				break
+			}
+			if !quoted && strings.IndexByte(" \t\n\r", c) >= 0 {
+				break
+			}
+			val.WriteByte(c)
+		}
+		if !closed {
+			return nil, fmt.Errorf("invalid key/value DSN: unterminated quoted value for %q", key)
+		}
+		pairs = append(pairs, [2]string{key, val.String()})
+		s = strings.TrimLeft(s[i:], " \t\n\r")
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("invalid key/value DSN: no parameters")
+	}
+	return pairs, nil
+}
+
+// isDSNKey reports whether s is a plausible connection parameter keyword.
+func isDSNKey(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// setPostgresParam applies a single Postgres connection parameter to cfg.
+func (cfg *DbDSNConfig) setPostgresParam(key, value string) error {
+	switch key {
+	case "host":
+		cfg.Server = value
+	case "port":
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid port %q: %w", value, err)
+		}
+		cfg.Port = port
+	case "dbname":
+		cfg.Name = value
+	case "user":
+		cfg.User = value
+	case "password":
+		cfg.Password = value
+	case "sslmode":
+		// DbDSN emitted "enable" before it was fixed to "require"; accept it so stored strings still parse.
+		if value == "enable" {
+			value = string(SSLRequire)
+		}
+		if err := cfg.setSSLMode(SSLMode(value)); err != nil {
+			return err
+		}
+	case "sslrootcert":
+		cfg.SSLRootCert = value
+	case "application_name":
+		cfg.ApplicationName = value
+	case "connect_timeout":
+		secs, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid connect_timeout %q: %w", value, err)
+		}
+		cfg.ConnectTimeout = time.Duration(secs) * time.Second
+	case "TimeZone", "timezone":
+		cfg.TimeZone = value
+	default:
+		cfg.setParam(key, value)
+	}
+	return nil
+}
+
+// setSSLMode validates and stores an explicit SSL mode, keeping the legacy SSLMode flag in sync.
+func (cfg *DbDSNConfig) setSSLMode(mode SSLMode) error {
+	if !mode.Valid() {
+		return fmt.Errorf("invalid sslmode %q", mode)
+	}
+	cfg.SSL = mode
+	cfg.SSLMode = mode != SSLDisable
+	return nil
+}
+
+// setParam stores an extra driver option in cfg.Params.
+func (cfg *DbDSNConfig) setParam(key, value string) {
+	if cfg.Params == nil {
+		cfg.Params = map[string]string{}
+	}
+	cfg.Params[key] = value
+}
+
+// parseDSNURL parses postgres://, mysql:// and sqlserver:// URLs.
+func parseDSNURL(dsn string) (DbDSNConfig, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return DbDSNConfig{}, fmt.Errorf("invalid DSN URL: %w", err)
+	}
+	var cfg DbDSNConfig
+	switch u.Scheme {
+	case "postgres", "postgresql":
+		cfg.Driver = DbDriverPostgres
+	case "mysql":
+		cfg.Driver = DbDriverMySQL
+	case "sqlserver":
+		cfg.Driver = DbDriverSQLServer
+	default:
+		return DbDSNConfig{}, fmt.Errorf("unsupported DSN scheme %q", u.Scheme)
+	}
+	if u.User != nil {
+		cfg.User = u.User.Username()
+		cfg.Password, _ = u.User.Password()
+	}
+	cfg.Server = u.Hostname()
+	if p := u.Port(); p != "" {
+		if cfg.Port, err = strconv.Atoi(p); err != nil {
+			return DbDSNConfig{}, fmt.Errorf("invalid port %q: %w", p, err)
+		}
+	}
+	cfg.Name = strings.TrimPrefix(u.Path, "/")
+
+	for key, values := range u.Query() {
+		value := values[len(values)-1]
+		switch cfg.Driver {
+		case DbDriverPostgres:
+			err = cfg.setPostgresParam(key, value)
+		case DbDriverMySQL:
+			err = cfg.setMySQLParam(key, value)
+		case DbDriverSQLServer:
+			err = cfg.setSQLServerParam(key, value)
+		}
+		if err != nil {
+			return DbDSNConfig{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// parseMySQLDSN parses a go-sql-driver/mysql DSN, [user[:password]@][tcp[(host:port)]]/dbname[?params].
+// Like the driver it splits on the last "/" and the last "@" before it, so passwords may contain both
+// ":" and "@". Only the tcp network is supported since DbDSNConfig has no socket path.
+func parseMySQLDSN(dsn string) (DbDSNConfig, error) {
+	cfg := DbDSNConfig{Driver: DbDriverMySQL}
+	slash := strings.LastIndex(dsn, "/")
+	prefix, rest := dsn[:slash], dsn[slash+1:]
+	if at := strings.LastIndex(prefix, "@"); at >= 0 {
+		cfg.User, cfg.Password, _ = strings.Cut(prefix[:at], ":")
+		prefix = prefix[at+1:]
+	}
+	if prefix != "" {
+		network, addr, _ := strings.Cut(prefix, "(")
+		if network != "tcp" {
+			return DbDSNConfig{}, fmt.Errorf("invalid MySQL DSN: unsupported network %q", network)
+		}
+		if addr != "" {
+			if !strings.HasSuffix(addr, ")") {
+				return DbDSNConfig{}, fmt.Errorf("invalid MySQL DSN: unterminated address in %q", prefix)
+			}
+			host, port, err := net.SplitHostPort(strings.TrimSuffix(addr, ")"))
+			if err != nil {
+				return DbDSNConfig{}, fmt.Errorf("invalid MySQL DSN address: %w", err)
+			}
+			if cfg.Port, err = strconv.Atoi(port); err != nil {
+				return DbDSNConfig{}, fmt.Errorf("invalid port %q: %w", port, err)
+			}
+			cfg.Server = host
+		}
+	}
+	rawName, rawQuery, _ := strings.Cut(rest, "?")
+	name, err := url.PathUnescape(rawName)
+	if err != nil {
+		return DbDSNConfig{}, fmt.Errorf("invalid MySQL database name %q: %w", rawName, err)
+	}
+	cfg.Name = name
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return DbDSNConfig{}, fmt.Errorf("invalid MySQL options: %w", err)
+	}
+	for key, values := range q {
+		if err := cfg.setMySQLParam(key, values[len(values)-1]); err != nil {
+			return DbDSNConfig{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// setMySQLParam applies a go-sql-driver/mysql query option to cfg.
+func (cfg *DbDSNConfig) setMySQLParam(key, value string) error {
+	switch key {
+	case "tls":
+		switch value {
+		case "false":
+			return cfg.setSSLMode(SSLDisable)
+		case "preferred":
+			return cfg.setSSLMode(SSLPrefer)
+		case "skip-verify":
+			return cfg.setSSLMode(SSLRequire)
+		case "true":
+			return cfg.setSSLMode(SSLVerifyFull)
+		}
+		cfg.setParam(key, value)
+	case "loc":
+		cfg.TimeZone = value
+	case "timeout":
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q: %w", value, err)
+		}
+		cfg.ConnectTimeout = d
+	default:
+		cfg.setParam(key, value)
+	}
+	return nil
+}
+
+// setSQLServerParam applies a sqlserver:// query option to cfg.
+func (cfg *DbDSNConfig) setSQLServerParam(key, value string) error {
+	switch strings.ToLower(key) {
+	case "database":
+		cfg.Name = value
+	case "app name":
+		cfg.ApplicationName = value
+	case "certificate":
+		cfg.SSLRootCert = value
+	case "connection timeout":
+		secs, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid connection timeout %q: %w", value, err)
+		}
+		cfg.ConnectTimeout = time.Duration(secs) * time.Second
+	case "encrypt":
+		switch value {
+		case "disable":
+			return cfg.setSSLMode(SSLDisable)
+		case "true":
+			if cfg.SSL != SSLRequire {
+				return cfg.setSSLMode(SSLVerifyFull)
+			}
+			return nil
+		}
+		cfg.setParam(key, value)
+	case "trustservercertificate":
+		if value == "true" {
+			return cfg.setSSLMode(SSLRequire)
+		}
+		cfg.setParam(key, value)
+	default:
+		cfg.setParam(key, value)
+	}
+	return nil
+}
+
+// parseSQLiteURI parses a SQLite file: URI into a config whose Name is the database path.
+func parseSQLiteURI(dsn string) (DbDSNConfig, error) {
+	rest := strings.TrimPrefix(dsn, "file:")
+	rawPath, rawQuery, _ := strings.Cut(rest, "?")
+	cfg := DbDSNConfig{Driver: DbDriverSQLite, Name: rawPath}
+	if rawPath != ":memory:" {
+		p, err := url.PathUnescape(rawPath)
+		if err != nil {
+			return DbDSNConfig{}, fmt.Errorf("invalid SQLite path %q: %w", rawPath, err)
+		}
+		cfg.Name = p
+	}
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return DbDSNConfig{}, fmt.Errorf("invalid SQLite options: %w", err)
+	}
+	for key, values := range q {
+		cfg.setParam(key, values[len(values)-1])
+	}
+	return cfg, nil
+}
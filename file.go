@@ -7,12 +7,16 @@ import (
 )
 
 // MimeTypeFromExtension returns the MIME type based on the file extension.
+// The built-in table (see LookupBuiltinMimeType) is consulted before the host's mime.types.
 // Defaults to "application/octet-stream" if unknown.
 func MimeTypeFromExtension(filename string) string {
 	ext := strings.ToLower(filepath.Ext(filename))
 	if ext == "" {
 		return "application/octet-stream"
 	}
+	if mimeType, ok := builtinMimeTypes[ext]; ok {
+		return mimeType
+	}
 	mimeType := mime.TypeByExtension(ext)
 	if mimeType == "" {
 		return "application/octet-stream"
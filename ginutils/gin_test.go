@@ -2,6 +2,8 @@ package ginutils_test
 
 import (
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -56,3 +58,27 @@ func TestExtractJwtFromContext_InvalidHeader(t *testing.T) {
 		t.Fatalf("expected error for missing/short Authorization header")
 	}
 }
+
+func TestSandboxFileHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	base := t.TempDir()
+	if err := os.WriteFile(filepath.Join(base, "report.pdf"), []byte("%PDF-1.4"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sb, err := utilities.NewSandbox(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sb.Close()
+	r := gin.New()
+	r.GET("/files/*name", ginutils.SandboxFileHandler(sb, "name"))
+
+	cases := map[string]int{"/files/report.pdf": 200, "/files/missing.pdf": 404, "/files/..%2f..%2fetc%2fpasswd": 400}
+	for target, want := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != want {
+			t.Errorf("%s: status=%d want %d", target, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package ginutils
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dan-sherwin/go-utilities"
+	"github.com/gin-gonic/gin"
+)
+
+// ServeSandboxFile serves the file name from sb, aborting with the status from utilities.SandboxHTTPStatus when the name is invalid, escapes the sandbox or does not exist.
+func ServeSandboxFile(c *gin.Context, sb *utilities.Sandbox, name string) {
+	if err := sb.ServeFile(c.Writer, c.Request, name); err != nil {
+		status := utilities.SandboxHTTPStatus(err)
+		c.AbortWithStatusJSON(status, gin.H{"error": http.StatusText(status)})
+	}
+}
+
+// SandboxFileHandler returns a handler serving the file named by route parameter param from sb, e.g. r.GET("/files/*name", SandboxFileHandler(sb, "name")). The leading slash of catch-all parameters is ignored.
+func SandboxFileHandler(sb *utilities.Sandbox, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ServeSandboxFile(c, sb, strings.TrimPrefix(c.Param(param), "/"))
+	}
+}
@@ -0,0 +1,146 @@
+package utilities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// defaultRetryBackoff is the first retry delay of OpenGormDB when DbOpenOptions.RetryBackoff is zero.
+const defaultRetryBackoff = 500 * time.Millisecond
+
+type (
+	// DbOpenOptions controls how OpenGormDB opens and tunes a *gorm.DB.
+	// Zero values leave the database/sql defaults in place; Attempts defaults to 1.
+	DbOpenOptions struct {
+		// Dialector builds the GORM dialector for the generated DSN. When nil, the Postgres and
+		// MySQL dialectors are used for those drivers; other drivers must supply one. Tests can
+		// return a dialector backed by SQLite or sqlmock and ignore the DSN.
+		Dialector func(dsn string) gorm.Dialector
+		// GormConfig is passed to gorm.Open; nil uses &gorm.Config{}.
+		GormConfig *gorm.Config
+
+		MaxOpenConns    int
+		MaxIdleConns    int
+		ConnMaxLifetime time.Duration
+		ConnMaxIdleTime time.Duration
+
+		// Attempts is the total number of connection attempts before giving up.
+		Attempts int
+		// RetryBackoff is the delay after the first failed attempt; it doubles after each
+		// further failure, capped at MaxRetryBackoff when that is set. Zero means 500ms.
+		RetryBackoff    time.Duration
+		MaxRetryBackoff time.Duration
+	}
+)
+
+// OpenGormDB opens a *gorm.DB for cfg, retrying the initial connection with exponential backoff,
+// applying the pool settings from opts and verifying the connection with a ping.
+// Retries are logged via slog with the redacted DSN. The context bounds the whole retry loop.
+func OpenGormDB(ctx context.Context, cfg DbDSNConfig, opts DbOpenOptions) (*gorm.DB, error) {
+	dsn, err := DbDSNFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	newDialector := opts.Dialector
+	if newDialector == nil {
+		switch cfg.Driver {
+		case "", DbDriverPostgres:
+			newDialector = postgres.Open
+		case DbDriverMySQL:
+			newDialector = mysql.Open
+		default:
+			return nil, fmt.Errorf("no default GORM dialector for driver %q; set DbOpenOptions.Dialector", cfg.Driver)
+		}
+	}
+	gormConfig := opts.GormConfig
+	if gormConfig == nil {
+		gormConfig = &gorm.Config{}
+	}
+	attempts := opts.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	backoff := opts.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		db, err := openAndPing(ctx, newDialector(dsn), gormConfig, opts)
+		if err == nil {
+			return db, nil
+		}
+		lastErr = err
+		if attempt == attempts {
+			break
+		}
+		slog.Warn("database connection failed; retrying",
+			slog.String("dsn", cfg.Redacted()),
+			slog.Int("attempt", attempt),
+			slog.Duration("backoff", backoff),
+			slog.Any("error", err))
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("database connection cancelled after %d attempt(s): %w", attempt, errors.Join(ctx.Err(), lastErr))
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if opts.MaxRetryBackoff > 0 && backoff > opts.MaxRetryBackoff {
+			backoff = opts.MaxRetryBackoff
+		}
+	}
+	return nil, fmt.Errorf("failed to connect to database after %d attempt(s): %w", attempts, lastErr)
+}
+
+// GormHealthCheck pings the database behind db and returns an error if it is unreachable.
+// It is suitable for readiness and liveness probes.
+func GormHealthCheck(ctx context.Context, db *gorm.DB) error {
+	if db == nil {
+		return fmt.Errorf("database is not initialized")
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database handle: %w", err)
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("database ping failed: %w", err)
+	}
+	return nil
+}
+
+// openAndPing performs a single gorm.Open, applies pool settings and pings the connection.
+func openAndPing(ctx context.Context, dialector gorm.Dialector, gormConfig *gorm.Config, opts DbOpenOptions) (*gorm.DB, error) {
+	db, err := gorm.Open(dialector, gormConfig)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if opts.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+	if opts.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,113 @@
+package utilities_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// flakyDialector fails Initialize until failures reaches zero, then delegates to the wrapped dialector.
+type flakyDialector struct {
+	gorm.Dialector
+	failures *int
+}
+
+func (d flakyDialector) Initialize(db *gorm.DB) error {
+	if *d.failures > 0 {
+		*d.failures--
+		return errors.New("connection refused")
+	}
+	return d.Dialector.Initialize(db)
+}
+
+func TestOpenGormDB_SQLite(t *testing.T) {
+	cfg := utilities.DbDSNConfig{Driver: utilities.DbDriverSQLite, Name: filepath.Join(t.TempDir(), "app.db")}
+	db, err := utilities.OpenGormDB(context.Background(), cfg, utilities.DbOpenOptions{
+		Dialector:       func(dsn string) gorm.Dialector { return sqlite.Open(dsn) },
+		MaxOpenConns:    3,
+		MaxIdleConns:    2,
+		ConnMaxLifetime: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("OpenGormDB error: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = sqlDB.Close() }()
+	if got := sqlDB.Stats().MaxOpenConnections; got != 3 {
+		t.Errorf("MaxOpenConnections=%d want 3", got)
+	}
+	if err := utilities.GormHealthCheck(context.Background(), db); err != nil {
+		t.Errorf("GormHealthCheck error: %v", err)
+	}
+	_ = sqlDB.Close()
+	if err := utilities.GormHealthCheck(context.Background(), db); err == nil {
+		t.Errorf("expected GormHealthCheck error on closed database")
+	}
+}
+
+func TestOpenGormDB_Retries(t *testing.T) {
+	cfg := utilities.DbDSNConfig{Driver: utilities.DbDriverSQLite, Name: ":memory:"}
+	failures := 2
+	opts := utilities.DbOpenOptions{
+		Dialector: func(dsn string) gorm.Dialector {
+			return flakyDialector{Dialector: sqlite.Open(dsn), failures: &failures}
+		},
+		Attempts:     3,
+		RetryBackoff: time.Millisecond,
+	}
+	db, err := utilities.OpenGormDB(context.Background(), cfg, opts)
+	if err != nil {
+		t.Fatalf("OpenGormDB should succeed on third attempt: %v", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+
+	failures = 5
+	if _, err := utilities.OpenGormDB(context.Background(), cfg, opts); err == nil {
+		t.Fatalf("expected error after exhausting attempts")
+	}
+}
+
+func TestOpenGormDB_ContextCancelledAndDefaults(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	failures := 10
+	_, err := utilities.OpenGormDB(ctx, utilities.DbDSNConfig{Driver: utilities.DbDriverSQLite, Name: ":memory:"}, utilities.DbOpenOptions{
+		Dialector: func(dsn string) gorm.Dialector {
+			return flakyDialector{Dialector: sqlite.Open(dsn), failures: &failures}
+		},
+		Attempts:     10,
+		RetryBackoff: time.Hour,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// without RetryBackoff the retry still waits, so a short deadline expires before the second attempt
+	failures = 1
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = utilities.OpenGormDB(ctx, utilities.DbDSNConfig{Driver: utilities.DbDriverSQLite, Name: ":memory:"}, utilities.DbOpenOptions{
+		Dialector: func(dsn string) gorm.Dialector {
+			return flakyDialector{Dialector: sqlite.Open(dsn), failures: &failures}
+		},
+		Attempts: 2,
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the default backoff to outlast the deadline, got %v", err)
+	}
+
+	if _, err := utilities.OpenGormDB(context.Background(), utilities.DbDSNConfig{Driver: utilities.DbDriverSQLServer, Server: "db", Name: "x"}, utilities.DbOpenOptions{}); err == nil {
+		t.Errorf("expected error when no dialector is available for the driver")
+	}
+}
@@ -0,0 +1,201 @@
+package utilities
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HostInfo holds host facts gathered from /proc and /etc by CollectHostInfo. It is a flat struct so
+// it can be shown with PrintStructTable or sent to an inventory service as JSON. Memory sizes are
+// in bytes.
+type HostInfo struct {
+	Hostname     string        `json:"hostname"`
+	FQDN         string        `json:"fqdn"`
+	OSID         string        `json:"os_id"`
+	OSName       string        `json:"os_name"`
+	OSVersion    string        `json:"os_version"`
+	OSPrettyName string        `json:"os_pretty_name"`
+	Kernel       string        `json:"kernel"`
+	Uptime       time.Duration `json:"uptime"`
+	CPUCount     int           `json:"cpu_count"`
+	CPUModel     string        `json:"cpu_model"`
+	MemTotal     uint64        `json:"mem_total"`
+	MemAvailable uint64        `json:"mem_available"`
+	SwapTotal    uint64        `json:"swap_total"`
+	Load1        float64       `json:"load1"`
+	Load5        float64       `json:"load5"`
+	Load15       float64       `json:"load15"`
+	BootID       string        `json:"boot_id"`
+	MachineID    string        `json:"machine_id"`
+}
+
+// CollectHostInfo gathers HostInfo for the running Linux host; see CollectHostInfoFrom.
+func CollectHostInfo() (*HostInfo, error) {
+	return CollectHostInfoFrom("/")
+}
+
+// CollectHostInfoFrom gathers HostInfo reading proc and etc below root instead of "/", which lets
+// tests supply a fake filesystem. Every source is read independently: the returned HostInfo holds
+// whatever could be collected and the error joins the failures, if any. The FQDN comes from the
+// hostname when it is qualified, then from etc/hosts and, only for the real root, from DNS.
+func CollectHostInfoFrom(root string) (*HostInfo, error) {
+	h := &HostInfo{}
+	var errs []error
+	// read returns the trimmed contents of the first readable file of names below root
+	read := func(names ...string) string {
+		var err error
+		for _, name := range names {
+			var b []byte
+			if b, err = os.ReadFile(filepath.Join(root, name)); err == nil {
+				return strings.TrimSpace(string(b))
+			}
+		}
+		errs = append(errs, err)
+		return ""
+	}
+
+	h.Hostname = read("proc/sys/kernel/hostname", "etc/hostname")
+	h.FQDN = hostFQDN(root, h.Hostname)
+	h.Kernel = read("proc/sys/kernel/osrelease")
+	h.BootID = read("proc/sys/kernel/random/boot_id")
+	h.MachineID = read("etc/machine-id", "var/lib/dbus/machine-id")
+
+	if release, err := readOSRelease(root); err != nil {
+		errs = append(errs, err)
+	} else {
+		h.OSID, h.OSName, h.OSVersion, h.OSPrettyName = release["ID"], release["NAME"], release["VERSION"], release["PRETTY_NAME"]
+		if h.OSVersion == "" {
+			h.OSVersion = release["VERSION_ID"]
+		}
+	}
+
+	if fields := strings.Fields(read("proc/uptime")); len(fields) > 0 {
+		if secs, err := strconv.ParseFloat(fields[0], 64); err == nil {
+			h.Uptime = time.Duration(secs * float64(time.Second)).Truncate(time.Second)
+		}
+	}
+	if fields := strings.Fields(read("proc/loadavg")); len(fields) >= 3 {
+		h.Load1, _ = strconv.ParseFloat(fields[0], 64)
+		h.Load5, _ = strconv.ParseFloat(fields[1], 64)
+		h.Load15, _ = strconv.ParseFloat(fields[2], 64)
+	}
+	parseCPUInfo(read("proc/cpuinfo"), h)
+	parseMemInfo(read("proc/meminfo"), h)
+	return h, errors.Join(errs...)
+}
+
+// readOSRelease parses etc/os-release, falling back to usr/lib/os-release as systemd specifies.
+func readOSRelease(root string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(root, "etc/os-release"))
+	if errors.Is(err, os.ErrNotExist) {
+		f, err = os.Open(filepath.Join(root, "usr/lib/os-release"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read os-release: %w", err)
+	}
+	defer f.Close()
+	release := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if len(value) >= 2 && value[0] == '"' {
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+		} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		release[key] = value
+	}
+	return release, scanner.Err()
+}
+
+// parseCPUInfo counts processors and picks the model name, which differs by architecture.
+func parseCPUInfo(cpuinfo string, h *HostInfo) {
+	models := map[string]string{}
+	for _, line := range strings.Split(cpuinfo, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if key == "processor" {
+			h.CPUCount++
+		}
+		if _, seen := models[key]; !seen {
+			models[key] = value
+		}
+	}
+	for _, key := range []string{"model name", "cpu model", "Model", "Hardware", "cpu"} {
+		if models[key] != "" {
+			h.CPUModel = models[key]
+			return
+		}
+	}
+}
+
+// parseMemInfo reads the kB values of /proc/meminfo into bytes.
+func parseMemInfo(meminfo string, h *HostInfo) {
+	for _, line := range strings.Split(meminfo, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			h.MemTotal = kb * 1024
+		case "MemAvailable:":
+			h.MemAvailable = kb * 1024
+		case "SwapTotal:":
+			h.SwapTotal = kb * 1024
+		}
+	}
+}
+
+// hostFQDN resolves the fully qualified name of hostname like `hostname -f`.
+func hostFQDN(root, hostname string) string {
+	if hostname == "" || strings.Contains(hostname, ".") {
+		return hostname
+	}
+	if f, err := os.Open(filepath.Join(root, "etc/hosts")); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line, _, _ := strings.Cut(scanner.Text(), "#")
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			for _, name := range fields[1:] {
+				if strings.HasPrefix(name, hostname+".") {
+					return name
+				}
+			}
+		}
+	}
+	if root != "/" {
+		return hostname
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if cname, err := net.DefaultResolver.LookupCNAME(ctx, hostname); err == nil && strings.Contains(strings.TrimSuffix(cname, "."), ".") {
+		return strings.TrimSuffix(cname, ".")
+	}
+	return hostname
+}
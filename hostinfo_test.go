@@ -0,0 +1,109 @@
+package utilities_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func writeFakeRoot(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestCollectHostInfoFrom_FakeRoot(t *testing.T) {
+	root := writeFakeRoot(t, map[string]string{
+		"proc/sys/kernel/hostname":       "web1\n",
+		"proc/sys/kernel/osrelease":      "6.8.0-45-generic\n",
+		"proc/sys/kernel/random/boot_id": "3f1c2a9e-0b7d-4c55-9a61-2f8e4d7c1b90\n",
+		"etc/machine-id":                 "0123456789abcdef0123456789abcdef\n",
+		"etc/hosts":                      "127.0.0.1 localhost\n10.0.0.5 web1.example.com web1 # primary\n",
+		"etc/os-release":                 "# comment\nNAME=\"Ubuntu\"\nVERSION=\"24.04.1 LTS (Noble Numbat)\"\nID=ubuntu\nPRETTY_NAME=\"Ubuntu 24.04.1 LTS\"\n",
+		"proc/uptime":                    "3725.42 7000.00\n",
+		"proc/loadavg":                   "0.52 0.33 0.10 1/234 5678\n",
+		"proc/cpuinfo":                   "processor\t: 0\nmodel name\t: Example CPU @ 3.00GHz\n\nprocessor\t: 1\nmodel name\t: Example CPU @ 3.00GHz\n",
+		"proc/meminfo":                   "MemTotal:       16384000 kB\nMemFree:         1000 kB\nMemAvailable:    8192000 kB\nSwapTotal:       2048000 kB\n",
+	})
+	info, err := utilities.CollectHostInfoFrom(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := utilities.HostInfo{
+		Hostname:     "web1",
+		FQDN:         "web1.example.com",
+		OSID:         "ubuntu",
+		OSName:       "Ubuntu",
+		OSVersion:    "24.04.1 LTS (Noble Numbat)",
+		OSPrettyName: "Ubuntu 24.04.1 LTS",
+		Kernel:       "6.8.0-45-generic",
+		Uptime:       time.Hour + 2*time.Minute + 5*time.Second,
+		CPUCount:     2,
+		CPUModel:     "Example CPU @ 3.00GHz",
+		MemTotal:     16384000 * 1024,
+		MemAvailable: 8192000 * 1024,
+		SwapTotal:    2048000 * 1024,
+		Load1:        0.52,
+		Load5:        0.33,
+		Load15:       0.10,
+		BootID:       "3f1c2a9e-0b7d-4c55-9a61-2f8e4d7c1b90",
+		MachineID:    "0123456789abcdef0123456789abcdef",
+	}
+	if *info != want {
+		t.Errorf("got  %+v\nwant %+v", *info, want)
+	}
+	out, err := captureStdout(func() error { return utilities.PrintStructTable(info) })
+	if err != nil {
+		t.Fatalf("PrintStructTable: %v", err)
+	}
+	for _, want := range []string{"FQDN", "web1.example.com", "Ubuntu 24.04.1 LTS", "1h2m5s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCollectHostInfoFrom_FallbacksAndPartial(t *testing.T) {
+	root := writeFakeRoot(t, map[string]string{
+		"etc/hostname":            "db2\n",
+		"var/lib/dbus/machine-id": "feedface\n",
+		"usr/lib/os-release":      "ID=debian\nVERSION_ID='12'\n",
+		"proc/cpuinfo":            "processor\t: 0\nHardware\t: BCM2835\n",
+	})
+	info, err := utilities.CollectHostInfoFrom(root)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected joined not-exist errors, got %v", err)
+	}
+	if info.Hostname != "db2" || info.FQDN != "db2" || info.MachineID != "feedface" {
+		t.Errorf("hostname/machine id fallbacks: %+v", info)
+	}
+	if info.OSID != "debian" || info.OSVersion != "12" || info.CPUCount != 1 || info.CPUModel != "BCM2835" {
+		t.Errorf("os-release/cpuinfo: %+v", info)
+	}
+}
+
+func TestCollectHostInfo_Live(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("host info is read from Linux /proc")
+	}
+	info, _ := utilities.CollectHostInfo()
+	if info.Kernel == "" || info.CPUCount < 1 || info.MemTotal == 0 || info.Uptime <= 0 {
+		t.Errorf("incomplete live host info: %+v", info)
+	}
+}
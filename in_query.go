@@ -0,0 +1,223 @@
+package utilities
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrEmptyInList is returned by ExpandInQuery when a slice argument has no elements,
+// since "IN ()" is not valid SQL.
+var ErrEmptyInList = errors.New("empty slice passed for IN list")
+
+// DbMaxParams returns the maximum number of bind parameters a single statement may use
+// for driver: 65535 for Postgres and MySQL, 32766 for SQLite and 2100 for SQL Server.
+func DbMaxParams(driver DbDriver) int {
+	switch driver {
+	case DbDriverSQLServer:
+		return 2100
+	case DbDriverSQLite:
+		return 32766
+	default:
+		return 65535
+	}
+}
+
+// InChunkSize returns how many IN-list values fit in one statement for driver when the
+// query also binds otherParams fixed parameters. It never returns less than 1.
+func InChunkSize(driver DbDriver, otherParams int) int {
+	n := DbMaxParams(driver) - otherParams
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// ChunkSlice splits in into consecutive chunks of at most size elements. A size below 1 returns in as a single chunk.
+func ChunkSlice[T any](in []T, size int) [][]T {
+	if len(in) == 0 {
+		return nil
+	}
+	if size < 1 || size >= len(in) {
+		return [][]T{in}
+	}
+	chunks := make([][]T, 0, (len(in)+size-1)/size)
+	for start := 0; start < len(in); start += size {
+		end := min(start+size, len(in))
+		chunks = append(chunks, in[start:end])
+	}
+	return chunks
+}
+
+// RebindQuery rewrites "?" placeholders in query to the bind style of driver: $1, $2… for
+// Postgres, @p1, @p2… for SQL Server and unchanged for MySQL and SQLite. Question marks inside
+// quoted strings, quoted identifiers and comments are left alone.
+func RebindQuery(driver DbDriver, query string) string {
+	if driver == DbDriverMySQL || driver == DbDriverSQLite {
+		return query
+	}
+	prefix := "$"
+	if driver == DbDriverSQLServer {
+		prefix = "@p"
+	}
+	var b strings.Builder
+	n := 0
+	scanPlaceholders(query, func(literal string, placeholder bool) {
+		if placeholder {
+			n++
+			b.WriteString(prefix + strconv.Itoa(n))
+			return
+		}
+		b.WriteString(literal)
+	})
+	return b.String()
+}
+
+// ExpandInQuery binds args to the "?" placeholders in query, expanding each slice argument
+// into a comma-separated list of placeholders ("id IN (?)" becomes "id IN (?, ?, ?)"), then
+// rebinds the result to driver's placeholder style. []byte and driver.Valuer arguments are
+// bound as single values; slices produced by ToValuers are expanded. Returns an error when
+// the placeholder and argument counts differ, a slice is empty, or the expanded statement
+// exceeds DbMaxParams for driver.
+func ExpandInQuery(driver DbDriver, query string, args ...any) (string, []any, error) {
+	var b strings.Builder
+	out := make([]any, 0, len(args))
+	i := 0
+	var err error
+	scanPlaceholders(query, func(literal string, placeholder bool) {
+		if !placeholder {
+			b.WriteString(literal)
+			return
+		}
+		if i >= len(args) {
+			err = errors.Join(err, fmt.Errorf("query has more placeholders than the %d argument(s) given", len(args)))
+			i++
+			return
+		}
+		arg := args[i]
+		i++
+		rv := reflect.ValueOf(arg)
+		if !isInListArg(rv) {
+			b.WriteString("?")
+			out = append(out, arg)
+			return
+		}
+		if rv.Len() == 0 {
+			err = errors.Join(err, fmt.Errorf("argument %d: %w", i, ErrEmptyInList))
+			return
+		}
+		for j := 0; j < rv.Len(); j++ {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString("?")
+			out = append(out, rv.Index(j).Interface())
+		}
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if i < len(args) {
+		return "", nil, fmt.Errorf("query has %d placeholder(s) but %d argument(s) were given", i, len(args))
+	}
+	if limit := DbMaxParams(driver); len(out) > limit {
+		return "", nil, fmt.Errorf("statement binds %d parameters; %s allows at most %d (use QueryInChunks)", len(out), driver, limit)
+	}
+	return RebindQuery(driver, b.String()), out, nil
+}
+
+// QueryInChunks splits values into chunks of at most chunkSize, calls fn for each chunk in
+// order and concatenates the results. It stops at the first error or when ctx is done.
+// Use InChunkSize to pick a chunk size that respects the driver's parameter limit.
+func QueryInChunks[T any, R any](ctx context.Context, values []T, chunkSize int, fn func(ctx context.Context, chunk []T) ([]R, error)) ([]R, error) {
+	var out []R
+	for i, chunk := range ChunkSlice(values, chunkSize) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rows, err := fn(ctx, chunk)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d: %w", i, err)
+		}
+		out = append(out, rows...)
+	}
+	return out, nil
+}
+
+// GormFindInChunks loads rows of R whose column is in values, issuing one "column IN ?" query
+// per chunk on db and merging the results. A chunkSize below 1 is derived from db's dialect
+// via InChunkSize. column is inserted verbatim and must be a trusted identifier.
+func GormFindInChunks[R any, T any](db *gorm.DB, column string, values []T, chunkSize int) ([]R, error) {
+	if chunkSize < 1 {
+		chunkSize = InChunkSize(DbDriver(db.Name()), 0)
+	}
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return QueryInChunks(ctx, values, chunkSize, func(ctx context.Context, chunk []T) ([]R, error) {
+		var rows []R
+		err := db.WithContext(ctx).Where(column+" IN ?", chunk).Find(&rows).Error
+		return rows, err
+	})
+}
+
+// isInListArg reports whether v should be expanded into an IN list.
+func isInListArg(v reflect.Value) bool {
+	if !v.IsValid() || (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) {
+		return false
+	}
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+	_, isValuer := v.Interface().(driver.Valuer)
+	return !isValuer
+}
+
+// scanPlaceholders walks query, calling emit with literal text runs and with placeholder=true for
+// each "?" outside single/double-quoted strings, backtick identifiers and SQL comments.
+func scanPlaceholders(query string, emit func(literal string, placeholder bool)) {
+	start := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; c {
+		case '\'', '"', '`':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 1
+			}
+		case '-':
+			if i+1 < len(query) && query[i+1] == '-' {
+				end := strings.IndexByte(query[i:], '\n')
+				if end < 0 {
+					i = len(query)
+				} else {
+					i += end
+				}
+			}
+		case '/':
+			if i+1 < len(query) && query[i+1] == '*' {
+				end := strings.Index(query[i+2:], "*/")
+				if end < 0 {
+					i = len(query)
+				} else {
+					i += end + 3
+				}
+			}
+		case '?':
+			emit(query[start:i], false)
+			emit("", true)
+			start = i + 1
+		}
+	}
+	if start < len(query) {
+		emit(query[start:], false)
+	}
+}
@@ -0,0 +1,123 @@
+package utilities_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestExpandInQuery(t *testing.T) {
+	query := "SELECT * FROM users WHERE tenant = ? AND id IN (?) AND note <> 'why?'"
+	cases := []struct {
+		driver utilities.DbDriver
+		want   string
+	}{
+		{utilities.DbDriverPostgres, "SELECT * FROM users WHERE tenant = $1 AND id IN ($2, $3, $4) AND note <> 'why?'"},
+		{utilities.DbDriverMySQL, "SELECT * FROM users WHERE tenant = ? AND id IN (?, ?, ?) AND note <> 'why?'"},
+		{utilities.DbDriverSQLServer, "SELECT * FROM users WHERE tenant = @p1 AND id IN (@p2, @p3, @p4) AND note <> 'why?'"},
+	}
+	for _, c := range cases {
+		got, args, err := utilities.ExpandInQuery(c.driver, query, "acme", []int{1, 2, 3})
+		if err != nil {
+			t.Fatalf("%s: ExpandInQuery error: %v", c.driver, err)
+		}
+		if got != c.want {
+			t.Errorf("%s:\n got %q\nwant %q", c.driver, got, c.want)
+		}
+		if !reflect.DeepEqual(args, []any{"acme", 1, 2, 3}) {
+			t.Errorf("%s: args=%v", c.driver, args)
+		}
+	}
+
+	// ToValuers output is expanded; []byte is bound as one value
+	_, args, err := utilities.ExpandInQuery(utilities.DbDriverPostgres, "SELECT ? WHERE id IN (?)", []byte("raw"), utilities.ToValuers([]myValuer{7, 8}))
+	if err != nil || len(args) != 3 {
+		t.Errorf("valuers: args=%v err=%v", args, err)
+	}
+
+	if _, _, err := utilities.ExpandInQuery(utilities.DbDriverPostgres, "id IN (?)", []int{}); !errors.Is(err, utilities.ErrEmptyInList) {
+		t.Errorf("expected ErrEmptyInList, got %v", err)
+	}
+	if _, _, err := utilities.ExpandInQuery(utilities.DbDriverPostgres, "a = ? AND b = ?", 1); err == nil {
+		t.Errorf("expected error for missing argument")
+	}
+	if _, _, err := utilities.ExpandInQuery(utilities.DbDriverPostgres, "a = ?", 1, 2); err == nil {
+		t.Errorf("expected error for extra argument")
+	}
+	if _, _, err := utilities.ExpandInQuery(utilities.DbDriverSQLServer, "id IN (?)", make([]int, 2101)); err == nil {
+		t.Errorf("expected error when exceeding SQL Server parameter limit")
+	}
+}
+
+func TestChunkSliceAndInChunkSize(t *testing.T) {
+	chunks := utilities.ChunkSlice([]int{1, 2, 3, 4, 5}, 2)
+	if !reflect.DeepEqual(chunks, [][]int{{1, 2}, {3, 4}, {5}}) {
+		t.Errorf("ChunkSlice=%v", chunks)
+	}
+	if utilities.ChunkSlice([]int{}, 2) != nil {
+		t.Errorf("ChunkSlice of empty should be nil")
+	}
+	if got := utilities.InChunkSize(utilities.DbDriverSQLServer, 2); got != 2098 {
+		t.Errorf("InChunkSize sqlserver=%d", got)
+	}
+	if got := utilities.InChunkSize(utilities.DbDriverPostgres, 70000); got != 1 {
+		t.Errorf("InChunkSize floor=%d", got)
+	}
+}
+
+func TestQueryInChunks(t *testing.T) {
+	calls := 0
+	out, err := utilities.QueryInChunks(context.Background(), []int{1, 2, 3, 4, 5}, 2, func(_ context.Context, chunk []int) ([]int, error) {
+		calls++
+		res := make([]int, len(chunk))
+		for i, v := range chunk {
+			res[i] = v * 10
+		}
+		return res, nil
+	})
+	if err != nil || calls != 3 || !reflect.DeepEqual(out, []int{10, 20, 30, 40, 50}) {
+		t.Errorf("QueryInChunks out=%v calls=%d err=%v", out, calls, err)
+	}
+
+	boom := errors.New("boom")
+	_, err = utilities.QueryInChunks(context.Background(), []int{1, 2, 3}, 1, func(_ context.Context, chunk []int) ([]int, error) {
+		if chunk[0] == 2 {
+			return nil, boom
+		}
+		return chunk, nil
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("expected wrapped boom, got %v", err)
+	}
+}
+
+func TestGormFindInChunks(t *testing.T) {
+	type widget struct {
+		ID   int
+		Name string
+	}
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&widget{}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 10; i++ {
+		if err := db.Create(&widget{ID: i, Name: "w"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	rows, err := utilities.GormFindInChunks[widget](db, "id", []int{1, 3, 5, 7, 9, 42}, 2)
+	if err != nil {
+		t.Fatalf("GormFindInChunks error: %v", err)
+	}
+	if len(rows) != 5 {
+		t.Errorf("GormFindInChunks returned %d rows, want 5", len(rows))
+	}
+}
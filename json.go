@@ -37,6 +37,16 @@ func ToJSONFile(v any, filename string) error {
 	return os.WriteFile(filename, data, 0644)
 }
 
+// ToJSONFileAtomic writes a value to a file in JSON format using WriteFileAtomic, so a crash
+// mid-write never leaves a truncated file. Existing permissions and ownership are kept.
+func ToJSONFileAtomic(v any, filename string) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filename, data, 0644)
+}
+
 // FromJSONFile reads a value from a JSON file.
 func FromJSONFile(filename string, v any) error {
 	data, err := os.ReadFile(filename)
@@ -0,0 +1,115 @@
+package utilities
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// JSONOf stores a value of type T in a JSON/JSONB column and reads it back as T.
+// It implements sql.Scanner, driver.Valuer, json.Marshaler/Unmarshaler and GORM's data type interfaces,
+// so it can be used directly as a model field:
+//
+//	type Order struct {
+//		ID      uint
+//		Payload utilities.JSONOf[Payload]
+//	}
+type JSONOf[T any] struct {
+	Data T
+}
+
+// NewJSONOf wraps v in a JSONOf[T].
+func NewJSONOf[T any](v T) JSONOf[T] {
+	return JSONOf[T]{Data: v}
+}
+
+// ConvertToJSONOf marshals input to JSON and decodes it into a JSONOf[T], converting between
+// types with compatible JSON representations (the typed counterpart of ConvertToJSONMap).
+func ConvertToJSONOf[T any](input any) (JSONOf[T], error) {
+	var out JSONOf[T]
+	if err := MarshalTo(input, &out.Data); err != nil {
+		return JSONOf[T]{}, fmt.Errorf("failed to convert to JSONOf: %w", err)
+	}
+	return out, nil
+}
+
+// Value implements driver.Valuer by marshaling Data to a JSON string.
+func (j JSONOf[T]) Value() (driver.Value, error) {
+	data, err := json.Marshal(j.Data)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner, decoding a JSON []byte or string into Data. NULL yields the zero value of T.
+func (j *JSONOf[T]) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		var zero T
+		j.Data = zero
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("failed to scan JSONOf value of type %T", value)
+	}
+	var out T
+	if err := json.Unmarshal(data, &out); err != nil {
+		return fmt.Errorf("failed to unmarshal JSONOf value: %w", err)
+	}
+	j.Data = out
+	return nil
+}
+
+// MarshalJSON encodes Data directly, without a wrapping object.
+func (j JSONOf[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Data)
+}
+
+// UnmarshalJSON decodes b directly into Data.
+func (j *JSONOf[T]) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &j.Data)
+}
+
+// GormDataType returns the generic GORM data type "json".
+func (JSONOf[T]) GormDataType() string {
+	return "json"
+}
+
+// GormDBDataType returns the column type for the active dialect: JSONB on Postgres, JSON on MySQL
+// and SQLite, and NVARCHAR(MAX) on SQL Server.
+func (JSONOf[T]) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	switch db.Name() {
+	case "postgres":
+		return "JSONB"
+	case "mysql", "sqlite":
+		return "JSON"
+	case "sqlserver":
+		return "NVARCHAR(MAX)"
+	}
+	return ""
+}
+
+// GormValue renders the value for GORM, casting to JSON on MySQL (but not MariaDB).
+func (j JSONOf[T]) GormValue(_ context.Context, db *gorm.DB) clause.Expr {
+	data, err := j.MarshalJSON()
+	if err != nil {
+		_ = db.AddError(err)
+		return gorm.Expr("NULL")
+	}
+	if v, ok := db.Dialector.(*mysql.Dialector); ok && !strings.Contains(v.ServerVersion, "MariaDB") {
+		return gorm.Expr("CAST(? AS JSON)", string(data))
+	}
+	return gorm.Expr("?", string(data))
+}
@@ -0,0 +1,84 @@
+package utilities_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type address struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+type customer struct {
+	ID      uint
+	Address utilities.JSONOf[address]
+	Tags    utilities.JSONOf[[]string]
+}
+
+func TestJSONOf_ScanValue(t *testing.T) {
+	j := utilities.NewJSONOf(address{City: "Oslo", Zip: "0150"})
+	v, err := j.Value()
+	if err != nil {
+		t.Fatalf("Value error: %v", err)
+	}
+	if v != `{"city":"Oslo","zip":"0150"}` {
+		t.Errorf("Value=%v", v)
+	}
+
+	var out utilities.JSONOf[address]
+	if err := out.Scan([]byte(`{"city":"Bergen"}`)); err != nil || out.Data.City != "Bergen" {
+		t.Errorf("Scan bytes: %+v err=%v", out, err)
+	}
+	if err := out.Scan(nil); err != nil || out.Data != (address{}) {
+		t.Errorf("Scan nil: %+v err=%v", out, err)
+	}
+	if err := out.Scan(42); err == nil {
+		t.Errorf("expected error scanning int")
+	}
+	if err := out.Scan(`{"city":`); err == nil {
+		t.Errorf("expected error scanning invalid JSON")
+	}
+
+	b, err := json.Marshal(struct {
+		A utilities.JSONOf[[]int] `json:"a"`
+	}{utilities.NewJSONOf([]int{1, 2})})
+	if err != nil || string(b) != `{"a":[1,2]}` {
+		t.Errorf("MarshalJSON: %s err=%v", b, err)
+	}
+}
+
+func TestConvertToJSONOf(t *testing.T) {
+	got, err := utilities.ConvertToJSONOf[address](map[string]any{"city": "Rome", "zip": "00100"})
+	if err != nil {
+		t.Fatalf("ConvertToJSONOf error: %v", err)
+	}
+	if got.Data != (address{City: "Rome", Zip: "00100"}) {
+		t.Errorf("ConvertToJSONOf=%+v", got.Data)
+	}
+}
+
+func TestJSONOf_GormRoundTrip(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&customer{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	in := customer{Address: utilities.NewJSONOf(address{City: "Lisbon", Zip: "1100"}), Tags: utilities.NewJSONOf([]string{"vip"})}
+	if err := db.Create(&in).Error; err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	var out customer
+	if err := db.First(&out, in.ID).Error; err != nil {
+		t.Fatalf("First: %v", err)
+	}
+	if out.Address.Data.City != "Lisbon" || len(out.Tags.Data) != 1 || out.Tags.Data[0] != "vip" {
+		t.Errorf("round trip mismatch: %+v", out)
+	}
+}
@@ -0,0 +1,239 @@
+package utilities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/datatypes"
+)
+
+// ErrJSONPathNotFound is returned when a JSON path does not resolve to a value.
+var ErrJSONPathNotFound = errors.New("json path not found")
+
+// jsonPathSegment is a single step in a parsed JSON path: an object key or an array index.
+type jsonPathSegment struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// JSONPathQuery resolves a JSONPath-style expression against doc and returns the raw decoded value
+// (map[string]any, []any, string, float64, bool or nil).
+//
+// Supported syntax is the common subset: "$.customer.address.city", "$.orders[0].id",
+// "$['key with spaces']" and "$.items[-1]" (negative indexes count from the end). The leading "$"
+// is optional. doc may be datatypes.JSON, datatypes.JSONMap, JSON, map[string]any, []byte, string
+// or any value that marshals to JSON (e.g. JSONOf[T] or a struct).
+func JSONPathQuery(doc any, path string) (any, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := jsonPathRoot(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, seg := range segments {
+		switch node := cur.(type) {
+		case map[string]any:
+			if seg.isIdx {
+				return nil, fmt.Errorf("%w: %s (index on object at segment %d)", ErrJSONPathNotFound, path, i)
+			}
+			v, ok := node[seg.key]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrJSONPathNotFound, path)
+			}
+			cur = v
+		case []any:
+			if !seg.isIdx {
+				return nil, fmt.Errorf("%w: %s (key on array at segment %d)", ErrJSONPathNotFound, path, i)
+			}
+			idx := seg.index
+			if idx < 0 {
+				idx += len(node)
+			}
+			if idx < 0 || idx >= len(node) {
+				return nil, fmt.Errorf("%w: %s (index %d out of range)", ErrJSONPathNotFound, path, seg.index)
+			}
+			cur = node[idx]
+		default:
+			return nil, fmt.Errorf("%w: %s (scalar at segment %d)", ErrJSONPathNotFound, path, i)
+		}
+	}
+	return cur, nil
+}
+
+// JSONPathGet resolves path against doc (see JSONPathQuery) and converts the result to T.
+// Values that are not directly of type T are converted through their JSON representation, so
+// numbers decode into int fields and objects into structs.
+func JSONPathGet[T any](doc any, path string) (T, error) {
+	var out T
+	v, err := JSONPathQuery(doc, path)
+	if err != nil {
+		return out, err
+	}
+	if typed, ok := v.(T); ok {
+		return typed, nil
+	}
+	if err := MarshalTo(v, &out); err != nil {
+		return out, fmt.Errorf("json path %s: cannot convert %T to %T: %w", path, v, out, err)
+	}
+	return out, nil
+}
+
+// JSONPathExists reports whether path resolves to a value (including JSON null) in doc.
+func JSONPathExists(doc any, path string) bool {
+	_, err := JSONPathQuery(doc, path)
+	return err == nil
+}
+
+// JSONPathPostgres returns a Postgres expression that extracts path from column using the -> operator,
+// with ->> for the final step when asText is true, e.g. data->'customer'->'address'->>'city'.
+// column is inserted verbatim and must be a trusted identifier.
+func JSONPathPostgres(column, path string, asText bool) (string, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(column)
+	for i, seg := range segments {
+		if asText && i == len(segments)-1 {
+			b.WriteString("->>")
+		} else {
+			b.WriteString("->")
+		}
+		if seg.isIdx && seg.index < 0 {
+			// parenthesize so the lexer does not read "->-" as a single operator
+			b.WriteString("(" + strconv.Itoa(seg.index) + ")")
+		} else if seg.isIdx {
+			b.WriteString(strconv.Itoa(seg.index))
+		} else {
+			b.WriteString("'" + strings.ReplaceAll(seg.key, "'", "''") + "'")
+		}
+	}
+	return b.String(), nil
+}
+
+// JSONPathMySQL returns a MySQL JSON_EXTRACT expression for path on column, wrapped in JSON_UNQUOTE
+// when unquote is true. column is inserted verbatim and must be a trusted identifier.
+func JSONPathMySQL(column, path string, unquote bool) (string, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+	var p strings.Builder
+	p.WriteString("$")
+	for _, seg := range segments {
+		switch {
+		case seg.isIdx && seg.index < 0:
+			p.WriteString("[last")
+			if seg.index < -1 {
+				p.WriteString("-" + strconv.Itoa(-seg.index-1))
+			}
+			p.WriteString("]")
+		case seg.isIdx:
+			p.WriteString("[" + strconv.Itoa(seg.index) + "]")
+		case isJSONPathIdent(seg.key):
+			p.WriteString("." + seg.key)
+		default:
+			p.WriteString(`."` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(seg.key) + `"`)
+		}
+	}
+	expr := fmt.Sprintf("JSON_EXTRACT(%s, '%s')", column, strings.ReplaceAll(p.String(), "'", "''"))
+	if unquote {
+		expr = "JSON_UNQUOTE(" + expr + ")"
+	}
+	return expr, nil
+}
+
+// jsonPathRoot normalizes the supported document types into a decoded JSON value. Maps such as
+// datatypes.JSONMap and JSON are round-tripped through encoding/json so nested Go values
+// (typed slices, structs) are traversable.
+func jsonPathRoot(doc any) (any, error) {
+	var raw []byte
+	switch v := doc.(type) {
+	case datatypes.JSON:
+		raw = v
+	case json.RawMessage:
+		raw = v
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		b, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal JSON document: %w", err)
+		}
+		raw = b
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON document: %w", err)
+	}
+	return out, nil
+}
+
+// parseJSONPath splits a JSONPath-style expression into key and index segments.
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	s := strings.TrimSpace(path)
+	s = strings.TrimPrefix(s, "$")
+	var segments []jsonPathSegment
+	for s != "" {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid json path %q: empty key", path)
+			}
+			segments = append(segments, jsonPathSegment{key: s[:end]})
+			s = s[end:]
+		case '[':
+			if len(s) > 1 && (s[1] == '\'' || s[1] == '"') {
+				end := strings.IndexByte(s[2:], s[1])
+				if end < 0 || len(s) < end+4 || s[end+3] != ']' {
+					return nil, fmt.Errorf("invalid json path %q: unterminated quoted key", path)
+				}
+				segments = append(segments, jsonPathSegment{key: s[2 : end+2]})
+				s = s[end+4:]
+				continue
+			}
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid json path %q: missing ]", path)
+			}
+			idx, err := strconv.Atoi(strings.TrimSpace(s[1:end]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid json path %q: bad index %q", path, s[1:end])
+			}
+			segments = append(segments, jsonPathSegment{index: idx, isIdx: true})
+			s = s[end+1:]
+		default:
+			if len(segments) > 0 {
+				return nil, fmt.Errorf("invalid json path %q: unexpected %q", path, s[0])
+			}
+			// bare leading key, e.g. "customer.address"
+			s = "." + s
+		}
+	}
+	return segments, nil
+}
+
+// isJSONPathIdent reports whether key can be written unquoted in a MySQL JSON path.
+func isJSONPathIdent(key string) bool {
+	for i, r := range key {
+		if r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9' {
+			continue
+		}
+		return false
+	}
+	return key != ""
+}
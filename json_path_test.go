@@ -0,0 +1,100 @@
+package utilities_test
+
+import (
+	"errors"
+	"testing"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+	"gorm.io/datatypes"
+)
+
+const orderDoc = `{"customer":{"name":"Ada","address":{"city":"London","zip code":"N1"}},"items":[{"sku":"A1","qty":2},{"sku":"B2","qty":5}],"paid":true,"note":null}`
+
+func TestJSONPathQuery_DocTypes(t *testing.T) {
+	jm, err := utilities.ConvertToJSONMap(map[string]any{"customer": map[string]any{"address": map[string]any{"city": "London"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := map[string]any{
+		"datatypes.JSON":    datatypes.JSON(orderDoc),
+		"datatypes.JSONMap": jm,
+		"utilities.JSON":    utilities.JSON{"customer": map[string]any{"address": map[string]string{"city": "London"}}},
+		"string":            orderDoc,
+	}
+	for name, doc := range docs {
+		city, err := utilities.JSONPathGet[string](doc, "$.customer.address.city")
+		if err != nil || city != "London" {
+			t.Errorf("%s: city=%q err=%v", name, city, err)
+		}
+	}
+}
+
+func TestJSONPathGet_Typed(t *testing.T) {
+	doc := datatypes.JSON(orderDoc)
+	if qty, err := utilities.JSONPathGet[int](doc, "$.items[1].qty"); err != nil || qty != 5 {
+		t.Errorf("qty=%d err=%v", qty, err)
+	}
+	if sku, err := utilities.JSONPathGet[string](doc, "items[-1].sku"); err != nil || sku != "B2" {
+		t.Errorf("last sku=%q err=%v", sku, err)
+	}
+	if zip, err := utilities.JSONPathGet[string](doc, "$.customer.address['zip code']"); err != nil || zip != "N1" {
+		t.Errorf("zip=%q err=%v", zip, err)
+	}
+	type item struct {
+		SKU string `json:"sku"`
+		Qty int    `json:"qty"`
+	}
+	if items, err := utilities.JSONPathGet[[]item](doc, "$.items"); err != nil || len(items) != 2 || items[0].SKU != "A1" {
+		t.Errorf("items=%+v err=%v", items, err)
+	}
+	if _, err := utilities.JSONPathGet[int](doc, "$.customer.name"); err == nil {
+		t.Errorf("expected conversion error for string -> int")
+	}
+	if _, err := utilities.JSONPathQuery(doc, "$.customer.phone"); !errors.Is(err, utilities.ErrJSONPathNotFound) {
+		t.Errorf("expected ErrJSONPathNotFound, got %v", err)
+	}
+	if _, err := utilities.JSONPathQuery(doc, "$.items[9]"); !errors.Is(err, utilities.ErrJSONPathNotFound) {
+		t.Errorf("expected ErrJSONPathNotFound for out of range, got %v", err)
+	}
+	if _, err := utilities.JSONPathQuery(doc, "$.items[x]"); err == nil || errors.Is(err, utilities.ErrJSONPathNotFound) {
+		t.Errorf("expected syntax error, got %v", err)
+	}
+	if !utilities.JSONPathExists(doc, "$.note") || utilities.JSONPathExists(doc, "$.missing") {
+		t.Errorf("JSONPathExists mismatch")
+	}
+}
+
+func TestJSONPathSQL(t *testing.T) {
+	pg := []struct {
+		path   string
+		asText bool
+		want   string
+	}{
+		{"$.customer.address.city", true, "data->'customer'->'address'->>'city'"},
+		{"$.items[0]", false, "data->'items'->0"},
+		{"$.items[-1].sku", true, "data->'items'->(-1)->>'sku'"},
+		{`$["o'brien"]`, true, "data->>'o''brien'"},
+	}
+	for _, c := range pg {
+		got, err := utilities.JSONPathPostgres("data", c.path, c.asText)
+		if err != nil || got != c.want {
+			t.Errorf("JSONPathPostgres(%q)=%q err=%v want %q", c.path, got, err, c.want)
+		}
+	}
+
+	my := []struct {
+		path    string
+		unquote bool
+		want    string
+	}{
+		{"$.customer.address.city", true, "JSON_UNQUOTE(JSON_EXTRACT(data, '$.customer.address.city'))"},
+		{"$.items[-1].sku", false, "JSON_EXTRACT(data, '$.items[last].sku')"},
+		{"$.customer['zip code']", false, `JSON_EXTRACT(data, '$.customer."zip code"')`},
+	}
+	for _, c := range my {
+		got, err := utilities.JSONPathMySQL("data", c.path, c.unquote)
+		if err != nil || got != c.want {
+			t.Errorf("JSONPathMySQL(%q)=%q err=%v want %q", c.path, got, err, c.want)
+		}
+	}
+}
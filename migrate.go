@@ -0,0 +1,408 @@
+package utilities
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Migration is a single versioned schema change loaded from "<version>_<name>.up.sql" and
+	// the optional matching "<version>_<name>.down.sql".
+	Migration struct {
+		Version int64
+		Name    string
+		Up      string
+		Down    string
+	}
+
+	// MigrationStatus describes whether a migration has been applied; it prints well with PrintStructTable.
+	MigrationStatus struct {
+		Version   int64
+		Name      string
+		Applied   bool
+		AppliedAt string
+	}
+
+	// Migrator applies migrations from an fs.FS (e.g. an embed.FS) to a database, recording applied
+	// versions in Table and holding a database advisory lock so only one instance migrates at a time.
+	// Migrations run on a single pinned connection; each runs in its own transaction. MySQL
+	// connections must enable multiStatements for migrations containing several statements.
+	Migrator struct {
+		DB     *sql.DB
+		Driver DbDriver
+		FS     fs.FS
+		// Dir is the directory within FS holding the migration files; "." when empty.
+		Dir string
+		// Table records applied versions; "schema_migrations" when empty. It may be schema-qualified
+		// ("schema.table").
+		Table string
+		// DryRun makes Up and Down report what they would do without changing the database; no lock is
+		// taken and the migrations table is not created.
+		DryRun bool
+	}
+)
+
+var (
+	migrationFileRe = regexp.MustCompile(`^(\d+)_([^.]+)\.(up|down)\.sql$`)
+	sqlIdentRe      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+)
+
+// NewMigrator returns a Migrator for db using the migration files at the root of fsys.
+func NewMigrator(db *sql.DB, driver DbDriver, fsys fs.FS) *Migrator {
+	return &Migrator{DB: db, Driver: driver, FS: fsys, Dir: ".", Table: "schema_migrations"}
+}
+
+// LoadMigrations reads "<version>_<name>.up.sql" / ".down.sql" files from dir in fsys and returns
+// them sorted by version. Other files are ignored. Duplicate versions or a down file without an up
+// file are errors.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory %s: %w", dir, err)
+	}
+	byVersion := map[int64]*Migration{}
+	// bases keeps each version's "<version>_<name>" so "1_x" and "001_x" cannot silently merge
+	bases := map[int64]string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := migrationFileRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", e.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", e.Name(), err)
+		}
+		base := m[1] + "_" + m[2]
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+			bases[version] = base
+		} else if bases[version] != base {
+			return nil, fmt.Errorf("duplicate migration version %d: %q and %q", version, bases[version], base)
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+	out := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", mig.Version, mig.Name)
+		}
+		out = append(out, *mig)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Up applies all pending migrations in version order and returns the ones applied
+// (or, with DryRun, the ones that would be applied).
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withRun(ctx, func(conn *sql.Conn) error {
+		all, applied, err := m.load(ctx, conn, !m.DryRun)
+		if err != nil {
+			return err
+		}
+		for _, mig := range all {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if !m.DryRun {
+				if err := m.apply(ctx, conn, mig, true); err != nil {
+					return err
+				}
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the steps most recently applied migrations, newest first, and returns them
+// (or, with DryRun, the ones that would be rolled back).
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("down steps must be at least 1, got %d", steps)
+	}
+	var done []Migration
+	err := m.withRun(ctx, func(conn *sql.Conn) error {
+		all, applied, err := m.load(ctx, conn, !m.DryRun)
+		if err != nil {
+			return err
+		}
+		for i := len(all) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := all[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", mig.Version, mig.Name)
+			}
+			if !m.DryRun {
+				if err := m.apply(ctx, conn, mig, false); err != nil {
+					return err
+				}
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lists every known migration and whether it has been applied. Versions recorded in the
+// table but missing from FS are included with an empty Name. It does not modify the database: a
+// missing migrations table means nothing has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	all, applied, err := m.load(ctx, conn, false)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]MigrationStatus, 0, len(all))
+	for _, mig := range all {
+		at, ok := applied[mig.Version]
+		out = append(out, MigrationStatus{Version: mig.Version, Name: mig.Name, Applied: ok, AppliedAt: at})
+		delete(applied, mig.Version)
+	}
+	for version, at := range applied {
+		out = append(out, MigrationStatus{Version: version, Applied: true, AppliedAt: at})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// PrintStatus prints the result of Status as a table via PrintStructTable.
+func (m *Migrator) PrintStatus(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	return PrintStructTable(status)
+}
+
+// table returns the validated migrations table name.
+func (m *Migrator) table() (string, error) {
+	t := m.Table
+	if t == "" {
+		t = "schema_migrations"
+	}
+	if !sqlIdentRe.MatchString(t) {
+		return "", fmt.Errorf("invalid migrations table name %q", t)
+	}
+	return t, nil
+}
+
+// load returns the migrations from FS together with the applied versions and their timestamps.
+// With create the migrations table is created when missing; otherwise a missing table is treated
+// as no migrations applied and the schema is left untouched.
+func (m *Migrator) load(ctx context.Context, conn *sql.Conn, create bool) ([]Migration, map[int64]string, error) {
+	all, err := LoadMigrations(m.FS, m.Dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	table, err := m.table()
+	if err != nil {
+		return nil, nil, err
+	}
+	if create {
+		ddl := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at VARCHAR(64) NOT NULL)", table)
+		if m.Driver == DbDriverSQLServer {
+			ddl = fmt.Sprintf("IF OBJECT_ID(N'%s', N'U') IS NULL CREATE TABLE %s (version BIGINT PRIMARY KEY, name NVARCHAR(255) NOT NULL, applied_at NVARCHAR(64) NOT NULL)", table, table)
+		}
+		if _, err := conn.ExecContext(ctx, ddl); err != nil {
+			return nil, nil, fmt.Errorf("failed to create migrations table %s: %w", table, err)
+		}
+	} else {
+		exists, err := m.tableExists(ctx, conn, table)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exists {
+			return all, map[int64]string{}, nil
+		}
+	}
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT version, applied_at FROM %s", table))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read migrations table %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+	applied := map[int64]string{}
+	for rows.Next() {
+		var version int64
+		var at string
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, nil, err
+		}
+		applied[version] = at
+	}
+	return all, applied, rows.Err()
+}
+
+// tableExists reports whether the migrations table exists, using the driver's catalog. A
+// "schema.table" name is looked up in that schema (the database for MySQL, the attached database
+// for SQLite), otherwise in the connection's current schema.
+func (m *Migrator) tableExists(ctx context.Context, conn *sql.Conn, table string) (bool, error) {
+	schema, name, qualified := strings.Cut(table, ".")
+	if !qualified {
+		schema, name = "", table
+	}
+	var query string
+	args := []any{name}
+	switch m.Driver {
+	case "", DbDriverPostgres:
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = COALESCE(NULLIF($2, ''), current_schema()) AND table_name = $1"
+		args = append(args, schema)
+	case DbDriverMySQL:
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = ?"
+		args = []any{schema, name}
+	case DbDriverSQLite:
+		// the schema was validated by sqlIdentRe, so it is safe to interpolate
+		master := "sqlite_master"
+		if qualified {
+			master = schema + ".sqlite_master"
+		}
+		query = "SELECT COUNT(*) FROM " + master + " WHERE type = 'table' AND name = ?"
+	case DbDriverSQLServer:
+		query = "SELECT COUNT(*) FROM sys.tables t JOIN sys.schemas s ON s.schema_id = t.schema_id WHERE t.name = @p1 AND s.name = COALESCE(NULLIF(@p2, ''), SCHEMA_NAME())"
+		args = append(args, schema)
+	default:
+		return false, fmt.Errorf("unsupported migration driver %q", m.Driver)
+	}
+	var n int
+	if err := conn.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to check migrations table %s: %w", table, err)
+	}
+	return n > 0, nil
+}
+
+// apply runs one migration in a transaction and records (or removes) its version.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mig Migration, up bool) (err error) {
+	table, err := m.table()
+	if err != nil {
+		return err
+	}
+	direction, body := "up", mig.Up
+	record := RebindQuery(m.Driver, fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (?, ?, ?)", table))
+	args := []any{mig.Version, mig.Name, time.Now().UTC().Format(time.RFC3339)}
+	if !up {
+		direction, body = "down", mig.Down
+		record = RebindQuery(m.Driver, fmt.Sprintf("DELETE FROM %s WHERE version = ?", table))
+		args = []any{mig.Version}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if _, err = tx.ExecContext(ctx, body); err != nil {
+		return fmt.Errorf("migration %d_%s (%s) failed: %w", mig.Version, mig.Name, direction, err)
+	}
+	if _, err = tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("failed to record migration %d_%s (%s): %w", mig.Version, mig.Name, direction, err)
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	slog.Info("applied migration", slog.Int64("version", mig.Version), slog.String("name", mig.Name), slog.String("direction", direction))
+	return nil
+}
+
+// withRun runs fn under the migration lock, or on a plain connection for dry runs, which only read.
+func (m *Migrator) withRun(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	if !m.DryRun {
+		return m.withLock(ctx, fn)
+	}
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	return fn(conn)
+}
+
+// withLock pins a connection, takes the driver's advisory lock for the migrations table, runs fn
+// and releases the lock. SQLite has no advisory locks; its own write locking serializes migrators.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	table, err := m.table()
+	if err != nil {
+		return err
+	}
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	// lock queries other than Postgres's return a status: GET_LOCK 1 on success and 0 or NULL
+	// otherwise, sp_getapplock 0 or 1 on success and a negative value otherwise
+	var lock, unlock string
+	var lockArg any = table
+	switch m.Driver {
+	case "", DbDriverPostgres:
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(table))
+		lockArg = int64(h.Sum64())
+		lock, unlock = "SELECT pg_advisory_lock($1)", "SELECT pg_advisory_unlock($1)"
+	case DbDriverMySQL:
+		lock, unlock = "SELECT GET_LOCK(?, -1) = 1", "SELECT RELEASE_LOCK(?)"
+	case DbDriverSQLServer:
+		lock = "DECLARE @r int; EXEC @r = sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session'; SELECT CASE WHEN @r >= 0 THEN 1 ELSE 0 END"
+		unlock = "EXEC sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'"
+	}
+	if lock != "" {
+		if m.Driver == "" || m.Driver == DbDriverPostgres {
+			if _, err := conn.ExecContext(ctx, lock, lockArg); err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+		} else {
+			var acquired sql.NullBool
+			if err := conn.QueryRowContext(ctx, lock, lockArg).Scan(&acquired); err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			if !acquired.Bool {
+				return fmt.Errorf("failed to acquire migration lock on %s", table)
+			}
+		}
+		defer func() {
+			if _, uerr := conn.ExecContext(context.WithoutCancel(ctx), unlock, lockArg); uerr != nil && err == nil {
+				err = fmt.Errorf("failed to release migration lock: %w", uerr)
+			}
+		}()
+	}
+	return fn(conn)
+}
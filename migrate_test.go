@@ -0,0 +1,143 @@
+package utilities_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+	_ "github.com/glebarez/go-sqlite"
+)
+
+var migrationFS = fstest.MapFS{
+	"migrations/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);")},
+	"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	"migrations/0002_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD COLUMN email TEXT; CREATE INDEX users_email ON users(email);")},
+	"migrations/0002_add_email.down.sql":    {Data: []byte("DROP INDEX users_email; ALTER TABLE users DROP COLUMN email;")},
+	"migrations/README.md":                  {Data: []byte("ignored")},
+}
+
+func openMigrationDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migs, err := utilities.LoadMigrations(migrationFS, "migrations")
+	if err != nil {
+		t.Fatalf("LoadMigrations error: %v", err)
+	}
+	if len(migs) != 2 || migs[0].Version != 1 || migs[1].Name != "add_email" || migs[1].Down == "" {
+		t.Fatalf("unexpected migrations: %+v", migs)
+	}
+
+	bad := fstest.MapFS{"0003_orphan.down.sql": {Data: []byte("SELECT 1;")}}
+	if _, err := utilities.LoadMigrations(bad, "."); err == nil {
+		t.Errorf("expected error for down file without up file")
+	}
+	for _, second := range []string{"001_b.up.sql", "001_a.up.sql"} {
+		dup := fstest.MapFS{"1_a.up.sql": {Data: []byte("SELECT 1;")}, second: {Data: []byte("SELECT 2;")}}
+		if _, err := utilities.LoadMigrations(dup, "."); err == nil {
+			t.Errorf("expected error for duplicate versions 1_a and %s", second)
+		}
+	}
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	db := openMigrationDB(t)
+	m := utilities.NewMigrator(db, utilities.DbDriverSQLite, migrationFS)
+	m.Dir = "migrations"
+
+	// dry run reports pending migrations without applying them
+	m.DryRun = true
+	planned, err := m.Up(ctx)
+	if err != nil || len(planned) != 2 {
+		t.Fatalf("dry-run Up: planned=%v err=%v", planned, err)
+	}
+	if _, err := db.Exec("SELECT 1 FROM users"); err == nil {
+		t.Fatalf("dry run must not create tables")
+	}
+	if _, err := db.Exec("SELECT 1 FROM schema_migrations"); err == nil {
+		t.Fatalf("dry run must not create the migrations table")
+	}
+	if status, err := m.Status(ctx); err != nil || len(status) != 2 || status[0].Applied {
+		t.Fatalf("Status before any migration: %+v err=%v", status, err)
+	}
+	if _, err := db.Exec("SELECT 1 FROM schema_migrations"); err == nil {
+		t.Fatalf("Status must not create the migrations table")
+	}
+
+	m.DryRun = false
+	applied, err := m.Up(ctx)
+	if err != nil || len(applied) != 2 {
+		t.Fatalf("Up: applied=%v err=%v", applied, err)
+	}
+	if _, err := db.Exec("INSERT INTO users (name, email) VALUES ('ada', 'ada@example.com')"); err != nil {
+		t.Fatalf("schema not migrated: %v", err)
+	}
+	if again, err := m.Up(ctx); err != nil || len(again) != 0 {
+		t.Errorf("second Up should be a no-op: %v err=%v", again, err)
+	}
+
+	status, err := m.Status(ctx)
+	if err != nil || len(status) != 2 || !status[0].Applied || !status[1].Applied || status[1].AppliedAt == "" {
+		t.Fatalf("Status=%+v err=%v", status, err)
+	}
+	out, err := captureStdout(func() error { return m.PrintStatus(ctx) })
+	if err != nil || !strings.Contains(out, "add_email") {
+		t.Errorf("PrintStatus output=%q err=%v", out, err)
+	}
+
+	rolled, err := m.Down(ctx, 1)
+	if err != nil || len(rolled) != 1 || rolled[0].Version != 2 {
+		t.Fatalf("Down: %v err=%v", rolled, err)
+	}
+	status, _ = m.Status(ctx)
+	if !status[0].Applied || status[1].Applied {
+		t.Errorf("after Down status=%+v", status)
+	}
+
+	// a schema-qualified table name finds the same table
+	qualified := *m
+	qualified.Table = "main.schema_migrations"
+	if status, err := qualified.Status(ctx); err != nil || !status[0].Applied || status[1].Applied {
+		t.Errorf("qualified Status=%+v err=%v", status, err)
+	}
+	if _, err := m.Down(ctx, 0); err == nil {
+		t.Errorf("expected error for zero steps")
+	}
+}
+
+func TestMigrator_FailedMigrationRollsBack(t *testing.T) {
+	ctx := context.Background()
+	db := openMigrationDB(t)
+	fsys := fstest.MapFS{
+		"1_ok.up.sql":     {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"2_broken.up.sql": {Data: []byte("CREATE TABLE b (id INTEGER); THIS IS NOT SQL;")},
+	}
+	m := utilities.NewMigrator(db, utilities.DbDriverSQLite, fsys)
+	if _, err := m.Up(ctx); err == nil {
+		t.Fatalf("expected error from broken migration")
+	}
+	status, err := m.Status(ctx)
+	if err != nil || !status[0].Applied || status[1].Applied {
+		t.Fatalf("status after failure=%+v err=%v", status, err)
+	}
+	if _, err := db.Exec("SELECT 1 FROM b"); err == nil {
+		t.Errorf("table from failed migration should have been rolled back")
+	}
+
+	m.Table = "bad name; DROP"
+	if _, err := m.Status(ctx); err == nil {
+		t.Errorf("expected error for invalid table name")
+	}
+}
@@ -0,0 +1,409 @@
+package utilities
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// mimeSniffLen is how much of the content DetectMimeType inspects; enough to see the first few
+// entries of an Office document's zip container.
+const mimeSniffLen = 8192
+
+// builtinMimeTypes maps lower-case extensions to MIME types independently of the host's
+// mime.types, so results are the same on every machine.
+var builtinMimeTypes = map[string]string{
+	".7z":       "application/x-7z-compressed",
+	".avif":     "image/avif",
+	".bmp":      "image/bmp",
+	".bz2":      "application/x-bzip2",
+	".css":      "text/css; charset=utf-8",
+	".csv":      "text/csv; charset=utf-8",
+	".doc":      "application/msword",
+	".docx":     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".epub":     "application/epub+zip",
+	".gif":      "image/gif",
+	".gz":       "application/gzip",
+	".htm":      "text/html; charset=utf-8",
+	".html":     "text/html; charset=utf-8",
+	".ico":      "image/vnd.microsoft.icon",
+	".jar":      "application/java-archive",
+	".jpeg":     "image/jpeg",
+	".jpg":      "image/jpeg",
+	".js":       "text/javascript; charset=utf-8",
+	".json":     "application/json",
+	".markdown": "text/markdown; charset=utf-8",
+	".md":       "text/markdown; charset=utf-8",
+	".mjs":      "text/javascript; charset=utf-8",
+	".mp3":      "audio/mpeg",
+	".mp4":      "video/mp4",
+	".odp":      "application/vnd.oasis.opendocument.presentation",
+	".ods":      "application/vnd.oasis.opendocument.spreadsheet",
+	".odt":      "application/vnd.oasis.opendocument.text",
+	".pdf":      "application/pdf",
+	".png":      "image/png",
+	".ppt":      "application/vnd.ms-powerpoint",
+	".pptx":     "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".rar":      "application/vnd.rar",
+	".rtf":      "application/rtf",
+	".svg":      "image/svg+xml",
+	".tar":      "application/x-tar",
+	".tgz":      "application/gzip",
+	".tif":      "image/tiff",
+	".tiff":     "image/tiff",
+	".txt":      "text/plain; charset=utf-8",
+	".wasm":     "application/wasm",
+	".wav":      "audio/wav",
+	".webm":     "video/webm",
+	".webp":     "image/webp",
+	".woff":     "font/woff",
+	".woff2":    "font/woff2",
+	".xls":      "application/vnd.ms-excel",
+	".xlsx":     "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".xml":      "application/xml",
+	".xz":       "application/x-xz",
+	".yaml":     "application/yaml",
+	".yml":      "application/yaml",
+	".zip":      "application/zip",
+	".zst":      "application/zstd",
+}
+
+// MimeResolution is the outcome of ResolveMimeType.
+type MimeResolution struct {
+	// Extension is the type implied by the file name ("" when the extension is unknown).
+	Extension string
+	// Content is the type detected from the data.
+	Content string
+	// MimeType is the resolved type: the extension type when it is consistent with the content
+	// (it is usually more specific, e.g. text/csv over text/plain), otherwise the content type.
+	MimeType string
+	// Conflict is set when the extension and content disagree, e.g. an executable named photo.jpg.
+	Conflict bool
+}
+
+// LookupBuiltinMimeType returns the MIME type for ext (with or without the leading dot, any case)
+// from the built-in table, which does not depend on the host.
+func LookupBuiltinMimeType(ext string) (string, bool) {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	mimeType, ok := builtinMimeTypes[ext]
+	return mimeType, ok
+}
+
+// DetectMimeType sniffs the MIME type of the data read from r by its magic bytes. It recognizes
+// common images, PDF, archives (zip, gzip, bzip2, xz, zstd, 7z, rar, tar), Office Open XML and
+// OpenDocument files, legacy Office (OLE2) files, ELF and PE executables, JSON, XML (including SVG) and HTML; anything
+// else is classified by net/http.DetectContentType. At most 8 KiB is read.
+func DetectMimeType(r io.Reader) (string, error) {
+	buf := make([]byte, mimeSniffLen)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	return detectMimeBytes(buf[:n], n == mimeSniffLen), nil
+}
+
+// DetectMimeTypeFile sniffs the MIME type of the file at path. For zip files the central directory
+// is read, so Office documents are recognized even when their entries are far into the file.
+func DetectMimeTypeFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	mimeType, err := DetectMimeType(f)
+	if err != nil || mimeType != "application/zip" {
+		return mimeType, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return mimeType, nil
+	}
+	zr, err := zip.NewReader(f, fi.Size())
+	if err != nil {
+		return mimeType, nil
+	}
+	names := make([]string, len(zr.File))
+	for i, zf := range zr.File {
+		names[i] = zf.Name
+	}
+	odf := ""
+	if len(zr.File) > 0 && zr.File[0].Name == "mimetype" {
+		if rc, err := zr.File[0].Open(); err == nil {
+			b, _ := io.ReadAll(io.LimitReader(rc, 128))
+			rc.Close()
+			odf = string(b)
+		}
+	}
+	return zipMimeType(names, odf), nil
+}
+
+// ResolveMimeType combines MimeTypeFromExtension-style lookup on filename with content sniffing of
+// r and reports whether they conflict. Generic content results (plain text, octet-stream) and
+// container formats (a .docx detected only as zip) are treated as compatible with the extension.
+func ResolveMimeType(filename string, r io.Reader) (MimeResolution, error) {
+	content, err := DetectMimeType(r)
+	if err != nil {
+		return MimeResolution{}, err
+	}
+	return resolveMime(filename, content), nil
+}
+
+// ResolveMimeTypeFile is ResolveMimeType for the file at path, using DetectMimeTypeFile.
+func ResolveMimeTypeFile(path string) (MimeResolution, error) {
+	content, err := DetectMimeTypeFile(path)
+	if err != nil {
+		return MimeResolution{}, err
+	}
+	return resolveMime(path, content), nil
+}
+
+func resolveMime(filename, content string) MimeResolution {
+	res := MimeResolution{Content: content}
+	if ext := MimeTypeFromExtension(filename); ext != "application/octet-stream" {
+		res.Extension = ext
+	}
+	switch {
+	case res.Extension == "":
+		res.MimeType = content
+	case mimeCompatible(res.Extension, content):
+		res.MimeType = res.Extension
+	default:
+		res.MimeType = content
+		res.Conflict = true
+	}
+	return res
+}
+
+// mimeCompatible reports whether a content-sniffed type is consistent with an extension type.
+func mimeCompatible(ext, content string) bool {
+	e, c := mimeBase(ext), mimeBase(content)
+	switch {
+	case e == c, c == "application/octet-stream":
+		return true
+	case c == "text/plain":
+		return strings.HasPrefix(e, "text/") || e == "application/json" || e == "application/yaml" ||
+			e == "application/xml" || strings.HasSuffix(e, "+xml") || strings.HasSuffix(e, "+json")
+	case c == "application/json":
+		return strings.HasSuffix(e, "+json") || strings.HasPrefix(e, "text/")
+	case c == "application/xml", c == "text/xml":
+		return e == "application/xml" || e == "text/xml" || strings.HasSuffix(e, "+xml") || strings.HasPrefix(e, "text/")
+	case c == "application/zip":
+		return strings.HasSuffix(e, "+zip") || e == "application/java-archive" ||
+			strings.HasPrefix(e, "application/vnd.openxmlformats-officedocument.") ||
+			strings.HasPrefix(e, "application/vnd.oasis.opendocument.")
+	case c == "application/x-ole-storage":
+		return e == "application/msword" || e == "application/vnd.ms-excel" || e == "application/vnd.ms-powerpoint" ||
+			e == "application/vnd.ms-outlook"
+	case c == "application/gzip":
+		return e == "application/x-gzip"
+	}
+	return false
+}
+
+// mimeBase strips parameters such as "; charset=utf-8".
+func mimeBase(t string) string {
+	if base, _, err := mime.ParseMediaType(t); err == nil {
+		return base
+	}
+	return strings.TrimSpace(strings.SplitN(t, ";", 2)[0])
+}
+
+// detectMimeBytes classifies a content prefix; truncated reports whether more data followed.
+func detectMimeBytes(b []byte, truncated bool) string {
+	switch {
+	case bytes.HasPrefix(b, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case bytes.HasPrefix(b, []byte{0xFF, 0xD8, 0xFF}):
+		return "image/jpeg"
+	case bytes.HasPrefix(b, []byte("GIF87a")), bytes.HasPrefix(b, []byte("GIF89a")):
+		return "image/gif"
+	case len(b) >= 12 && bytes.Equal(b[:4], []byte("RIFF")) && bytes.Equal(b[8:12], []byte("WEBP")):
+		return "image/webp"
+	case len(b) >= 12 && bytes.Equal(b[:4], []byte("RIFF")) && bytes.Equal(b[8:12], []byte("WAVE")):
+		return "audio/wav"
+	case len(b) >= 12 && bytes.Equal(b[4:8], []byte("ftyp")) && (bytes.Equal(b[8:12], []byte("avif")) || bytes.Equal(b[8:12], []byte("avis"))):
+		return "image/avif"
+	case bytes.HasPrefix(b, []byte("BM")) && len(b) >= 14:
+		return "image/bmp"
+	case bytes.HasPrefix(b, []byte("II*\x00")), bytes.HasPrefix(b, []byte("MM\x00*")):
+		return "image/tiff"
+	case bytes.HasPrefix(b, []byte{0x00, 0x00, 0x01, 0x00}):
+		return "image/vnd.microsoft.icon"
+	case bytes.HasPrefix(b, []byte("%PDF-")):
+		return "application/pdf"
+	case bytes.HasPrefix(b, []byte("PK\x03\x04")):
+		names, odf := zipLocalNames(b)
+		return zipMimeType(names, odf)
+	case bytes.HasPrefix(b, []byte("PK\x05\x06")):
+		return "application/zip"
+	case bytes.HasPrefix(b, []byte{0x1F, 0x8B}):
+		return "application/gzip"
+	case bytes.HasPrefix(b, []byte("BZh")):
+		return "application/x-bzip2"
+	case bytes.HasPrefix(b, []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}):
+		return "application/x-xz"
+	case bytes.HasPrefix(b, []byte{0x28, 0xB5, 0x2F, 0xFD}):
+		return "application/zstd"
+	case bytes.HasPrefix(b, []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}):
+		return "application/x-7z-compressed"
+	case bytes.HasPrefix(b, []byte("Rar!\x1a\x07")):
+		return "application/vnd.rar"
+	case len(b) >= 262 && bytes.Equal(b[257:262], []byte("ustar")):
+		return "application/x-tar"
+	case bytes.HasPrefix(b, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}):
+		return "application/x-ole-storage"
+	case bytes.HasPrefix(b, []byte("{\\rtf")):
+		return "application/rtf"
+	case bytes.HasPrefix(b, []byte("\x00asm")):
+		return "application/wasm"
+	case bytes.HasPrefix(b, []byte("\x7fELF")):
+		return "application/x-executable"
+	case isPortableExecutable(b):
+		return "application/vnd.microsoft.portable-executable"
+	}
+
+	text := bytes.TrimLeft(bytes.TrimPrefix(b, []byte("\xEF\xBB\xBF")), " \t\r\n")
+	if len(text) > 0 && (text[0] == '{' || text[0] == '[') && looksLikeJSON(text, truncated) {
+		return "application/json"
+	}
+	if t := sniffMarkup(text); t != "" {
+		return t
+	}
+	return http.DetectContentType(b)
+}
+
+// isPortableExecutable checks for an MZ stub whose e_lfanew points at a "PE\0\0" signature.
+func isPortableExecutable(b []byte) bool {
+	if len(b) < 0x40 || !bytes.HasPrefix(b, []byte("MZ")) {
+		return false
+	}
+	off := int(binary.LittleEndian.Uint32(b[0x3c:]))
+	return off >= 0x40 && off+4 <= len(b) && bytes.Equal(b[off:off+4], []byte("PE\x00\x00"))
+}
+
+// looksLikeJSON reports whether b is valid JSON, or a valid JSON prefix when truncated.
+func looksLikeJSON(b []byte, truncated bool) bool {
+	if !truncated {
+		return json.Valid(b)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	for {
+		if _, err := dec.Token(); err != nil {
+			return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		}
+	}
+}
+
+// sniffMarkup recognizes XML (and SVG, RSS, Atom roots) and HTML documents.
+func sniffMarkup(text []byte) string {
+	lower := bytes.ToLower(text[:min(len(text), 1024)])
+	switch {
+	case bytes.HasPrefix(lower, []byte("<!doctype html")), bytes.HasPrefix(lower, []byte("<html")):
+		return "text/html; charset=utf-8"
+	case bytes.HasPrefix(lower, []byte("<svg")):
+		return "image/svg+xml"
+	case !bytes.HasPrefix(lower, []byte("<?xml")):
+		return ""
+	}
+	// find the root element after the prolog, comments and doctype
+	rest := lower
+	for {
+		i := bytes.IndexByte(rest, '<')
+		if i < 0 || i+1 >= len(rest) {
+			return "application/xml"
+		}
+		rest = rest[i+1:]
+		if rest[0] != '?' && rest[0] != '!' {
+			break
+		}
+	}
+	switch {
+	case bytes.HasPrefix(rest, []byte("svg")):
+		return "image/svg+xml"
+	case bytes.HasPrefix(rest, []byte("rss")):
+		return "application/rss+xml"
+	case bytes.HasPrefix(rest, []byte("feed")):
+		return "application/atom+xml"
+	case bytes.HasPrefix(rest, []byte("html")):
+		return "application/xhtml+xml"
+	}
+	return "application/xml"
+}
+
+// zipLocalNames lists entry names from the zip local file headers present in b, and the content
+// of a leading stored "mimetype" entry (OpenDocument/EPUB).
+func zipLocalNames(b []byte) (names []string, odf string) {
+	for off := 0; off+30 <= len(b) && bytes.Equal(b[off:off+4], []byte("PK\x03\x04")); {
+		flags := binary.LittleEndian.Uint16(b[off+6:])
+		method := binary.LittleEndian.Uint16(b[off+8:])
+		csize := int(binary.LittleEndian.Uint32(b[off+18:]))
+		nameLen := int(binary.LittleEndian.Uint16(b[off+26:]))
+		extraLen := int(binary.LittleEndian.Uint16(b[off+28:]))
+		start := off + 30
+		if start+nameLen > len(b) {
+			break
+		}
+		name := string(b[start : start+nameLen])
+		names = append(names, name)
+		data := start + nameLen + extraLen
+		if len(names) == 1 && name == "mimetype" && method == zip.Store && data+csize <= len(b) {
+			odf = string(b[data : data+csize])
+		}
+		if flags&0x8 == 0 {
+			off = data + csize
+			continue
+		}
+		// sizes follow the data in a descriptor when bit 3 is set; skip to the next header
+		next := bytes.Index(b[min(data, len(b)):], []byte("PK\x03\x04"))
+		if next < 0 {
+			break
+		}
+		off = data + next
+	}
+	return names, odf
+}
+
+// zipMimeType refines a zip container by its entry names.
+func zipMimeType(names []string, odf string) string {
+	if odf = strings.TrimSpace(odf); odf != "" && strings.HasPrefix(odf, "application/") {
+		return odf
+	}
+	hasContentTypes := false
+	for _, n := range names {
+		if n == "[Content_Types].xml" {
+			hasContentTypes = true
+		}
+	}
+	for _, n := range names {
+		switch {
+		case hasContentTypes && strings.HasPrefix(n, "word/"):
+			return builtinMimeTypes[".docx"]
+		case hasContentTypes && strings.HasPrefix(n, "xl/"):
+			return builtinMimeTypes[".xlsx"]
+		case hasContentTypes && strings.HasPrefix(n, "ppt/"):
+			return builtinMimeTypes[".pptx"]
+		case n == "META-INF/MANIFEST.MF":
+			return builtinMimeTypes[".jar"]
+		}
+	}
+	return "application/zip"
+}
+
+// String summarizes the resolution, e.g. "image/png (extension image/jpeg conflicts)".
+func (m MimeResolution) String() string {
+	if m.Conflict {
+		return fmt.Sprintf("%s (extension %s conflicts)", m.MimeType, m.Extension)
+	}
+	return m.MimeType
+}
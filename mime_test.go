@@ -0,0 +1,135 @@
+package utilities_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func zipBytes(t *testing.T, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, n := range names {
+		w, err := zw.Create(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte("<x/>"))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectMimeType(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write([]byte("hello"))
+	_ = gw.Close()
+
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "image/png"},
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0, 0, 0x10, 'J', 'F', 'I', 'F'}, "image/jpeg"},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00"), "image/gif"},
+		{"webp", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "image/webp"},
+		{"pdf", []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3"), "application/pdf"},
+		{"gzip", gz.Bytes(), "application/gzip"},
+		{"zip", zipBytes(t, "a.txt"), "application/zip"},
+		{"docx", zipBytes(t, "[Content_Types].xml", "_rels/.rels", "word/document.xml"), "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"xlsx", zipBytes(t, "[Content_Types].xml", "xl/workbook.xml"), "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{"ole", []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1, 0, 0}, "application/x-ole-storage"},
+		{"json", []byte("  {\"a\": [1, 2, {\"b\": null}]}\n"), "application/json"},
+		{"not json", []byte("{not json}"), "text/plain; charset=utf-8"},
+		{"xml", []byte("<?xml version=\"1.0\"?>\n<!-- c -->\n<config><a/></config>"), "application/xml"},
+		{"svg", []byte("<?xml version=\"1.0\"?><svg xmlns=\"http://www.w3.org/2000/svg\"/>"), "image/svg+xml"},
+		{"html", []byte("<!DOCTYPE html><html></html>"), "text/html; charset=utf-8"},
+		{"text", []byte("just some words"), "text/plain; charset=utf-8"},
+	}
+	for _, c := range cases {
+		got, err := utilities.DetectMimeType(bytes.NewReader(c.data))
+		if err != nil || got != c.want {
+			t.Errorf("%s: DetectMimeType=%q err=%v want %q", c.name, got, err, c.want)
+		}
+	}
+
+	// a large JSON document is recognized from its truncated prefix
+	big := "[" + strings.Repeat(`{"k":"v"},`, 2000) + `{"k":"v"}]`
+	if got, _ := utilities.DetectMimeType(strings.NewReader(big)); got != "application/json" {
+		t.Errorf("large JSON detected as %q", got)
+	}
+}
+
+func TestDetectMimeTypeFile_ZipCentralDirectory(t *testing.T) {
+	// a large first entry pushes the Office marker entries past the sniffed prefix
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.CreateHeader(&zip.FileHeader{Name: "[Content_Types].xml", Method: zip.Store})
+	_, _ = w.Write(bytes.Repeat([]byte("x"), 20000))
+	w, _ = zw.Create("ppt/presentation.xml")
+	_, _ = w.Write([]byte("<p/>"))
+	_ = zw.Close()
+	path := filepath.Join(t.TempDir(), "deck.pptx")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := utilities.DetectMimeType(bytes.NewReader(buf.Bytes())); err != nil || got != "application/zip" {
+		t.Errorf("stream detection=%q err=%v", got, err)
+	}
+	want := "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	if got, err := utilities.DetectMimeTypeFile(path); err != nil || got != want {
+		t.Errorf("DetectMimeTypeFile=%q err=%v", got, err)
+	}
+}
+
+func TestResolveMimeType(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	exe := make([]byte, 0x84)
+	copy(exe, "MZ")
+	exe[0x3c] = 0x80
+	copy(exe[0x80:], "PE\x00\x00")
+	cases := []struct {
+		name     string
+		data     []byte
+		want     string
+		conflict bool
+	}{
+		{"photo.png", png, "image/png", false},
+		{"photo.jpg", png, "image/png", true},
+		{"data.csv", []byte("a,b\n1,2\n"), "text/csv; charset=utf-8", false},
+		{"report.docx", zipBytes(t, "a.txt"), "application/vnd.openxmlformats-officedocument.wordprocessingml.document", false},
+		{"old.xls", []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}, "application/vnd.ms-excel", false},
+		{"invoice.pdf", exe, "application/vnd.microsoft.portable-executable", true},
+		{"noext", png, "image/png", false},
+		{"notes.txt", []byte("MZ is not always an executable"), "text/plain; charset=utf-8", false},
+	}
+	for _, c := range cases {
+		res, err := utilities.ResolveMimeType(c.name, bytes.NewReader(c.data))
+		if err != nil || res.MimeType != c.want || res.Conflict != c.conflict {
+			t.Errorf("%s: %+v err=%v want %q conflict=%v", c.name, res, err, c.want, c.conflict)
+		}
+	}
+}
+
+func TestLookupBuiltinMimeType(t *testing.T) {
+	if got, ok := utilities.LookupBuiltinMimeType("XLSX"); !ok || got != "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet" {
+		t.Errorf("LookupBuiltinMimeType(XLSX)=%q %v", got, ok)
+	}
+	if _, ok := utilities.LookupBuiltinMimeType(".nope"); ok {
+		t.Errorf("unexpected builtin type for .nope")
+	}
+	if got := utilities.MimeTypeFromExtension("sheet.YML"); got != "application/yaml" {
+		t.Errorf("MimeTypeFromExtension(sheet.YML)=%q", got)
+	}
+}
@@ -0,0 +1,200 @@
+package utilities
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// NullFromPtr converts a pointer into a sql.Null[T]; nil becomes an invalid (NULL) value.
+func NullFromPtr[T any](p *T) sql.Null[T] {
+	if p == nil {
+		return sql.Null[T]{}
+	}
+	return sql.Null[T]{V: *p, Valid: true}
+}
+
+// PtrFromNull converts a sql.Null[T] into a pointer; NULL becomes nil.
+func PtrFromNull[T any](n sql.Null[T]) *T {
+	if !n.Valid {
+		return nil
+	}
+	return Ptr(n.V)
+}
+
+// NullStringFromPtr converts a *string into a sql.NullString.
+func NullStringFromPtr(p *string) sql.NullString {
+	return sql.NullString{String: PtrVal(p), Valid: p != nil}
+}
+
+// PtrFromNullString converts a sql.NullString into a *string; NULL becomes nil.
+func PtrFromNullString(n sql.NullString) *string {
+	if !n.Valid {
+		return nil
+	}
+	return Ptr(n.String)
+}
+
+// NullInt64FromPtr converts a *int64 into a sql.NullInt64.
+func NullInt64FromPtr(p *int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: PtrVal(p), Valid: p != nil}
+}
+
+// PtrFromNullInt64 converts a sql.NullInt64 into a *int64; NULL becomes nil.
+func PtrFromNullInt64(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return Ptr(n.Int64)
+}
+
+// NullInt32FromPtr converts a *int32 into a sql.NullInt32.
+func NullInt32FromPtr(p *int32) sql.NullInt32 {
+	return sql.NullInt32{Int32: PtrVal(p), Valid: p != nil}
+}
+
+// PtrFromNullInt32 converts a sql.NullInt32 into a *int32; NULL becomes nil.
+func PtrFromNullInt32(n sql.NullInt32) *int32 {
+	if !n.Valid {
+		return nil
+	}
+	return Ptr(n.Int32)
+}
+
+// NullFloat64FromPtr converts a *float64 into a sql.NullFloat64.
+func NullFloat64FromPtr(p *float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: PtrVal(p), Valid: p != nil}
+}
+
+// PtrFromNullFloat64 converts a sql.NullFloat64 into a *float64; NULL becomes nil.
+func PtrFromNullFloat64(n sql.NullFloat64) *float64 {
+	if !n.Valid {
+		return nil
+	}
+	return Ptr(n.Float64)
+}
+
+// NullBoolFromPtr converts a *bool into a sql.NullBool.
+func NullBoolFromPtr(p *bool) sql.NullBool {
+	return sql.NullBool{Bool: PtrVal(p), Valid: p != nil}
+}
+
+// PtrFromNullBool converts a sql.NullBool into a *bool; NULL becomes nil.
+func PtrFromNullBool(n sql.NullBool) *bool {
+	if !n.Valid {
+		return nil
+	}
+	return Ptr(n.Bool)
+}
+
+// NullTimeFromPtr converts a *time.Time into a sql.NullTime.
+func NullTimeFromPtr(p *time.Time) sql.NullTime {
+	return sql.NullTime{Time: PtrVal(p), Valid: p != nil}
+}
+
+// PtrFromNullTime converts a sql.NullTime into a *time.Time; NULL becomes nil.
+func PtrFromNullTime(n sql.NullTime) *time.Time {
+	if !n.Valid {
+		return nil
+	}
+	return Ptr(n.Time)
+}
+
+// Nullable is a value that distinguishes three states, which PATCH-style APIs need:
+// absent (Present false), explicitly null (Present true, Valid false) and set (both true).
+// It implements sql.Scanner, driver.Valuer and JSON (un)marshaling. Tag fields with
+// `json:",omitzero"` to omit absent values when marshaling.
+type Nullable[T any] struct {
+	Data    T
+	Valid   bool
+	Present bool
+}
+
+// NullableOf returns a present, non-null Nullable holding v.
+func NullableOf[T any](v T) Nullable[T] {
+	return Nullable[T]{Data: v, Valid: true, Present: true}
+}
+
+// NullOf returns a present Nullable that is explicitly null.
+func NullOf[T any]() Nullable[T] {
+	return Nullable[T]{Present: true}
+}
+
+// NullableFromPtr returns a present Nullable that is null when p is nil.
+func NullableFromPtr[T any](p *T) Nullable[T] {
+	if p == nil {
+		return NullOf[T]()
+	}
+	return NullableOf(*p)
+}
+
+// Ptr returns a pointer to Data when the value is non-null; otherwise nil.
+func (n Nullable[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	return Ptr(n.Data)
+}
+
+// ValueOr returns Data when the value is non-null; otherwise def.
+func (n Nullable[T]) ValueOr(def T) T {
+	if !n.Valid {
+		return def
+	}
+	return n.Data
+}
+
+// ApplyTo updates *dst when the value is present: to a pointer to Data, or to nil for an explicit null.
+// Absent values leave *dst untouched. It returns whether *dst was updated.
+func (n Nullable[T]) ApplyTo(dst **T) bool {
+	if !n.Present || dst == nil {
+		return false
+	}
+	*dst = n.Ptr()
+	return true
+}
+
+// IsZero reports whether the value is absent; it lets encoding/json's omitzero skip absent fields.
+func (n Nullable[T]) IsZero() bool {
+	return !n.Present
+}
+
+// MarshalJSON encodes Data, or null when the value is null or absent.
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Data)
+}
+
+// UnmarshalJSON marks the value present and decodes b; a JSON null leaves it present but null.
+func (n *Nullable[T]) UnmarshalJSON(b []byte) error {
+	n.Present = true
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		var zero T
+		n.Data, n.Valid = zero, false
+		return nil
+	}
+	if err := json.Unmarshal(b, &n.Data); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// Scan implements sql.Scanner using the same conversions as sql.Null[T]; the result is always present.
+func (n *Nullable[T]) Scan(value any) error {
+	var sn sql.Null[T]
+	if err := sn.Scan(value); err != nil {
+		return err
+	}
+	n.Data, n.Valid, n.Present = sn.V, sn.Valid, true
+	return nil
+}
+
+// Value implements driver.Valuer, returning nil for null or absent values.
+func (n Nullable[T]) Value() (driver.Value, error) {
+	return sql.Null[T]{V: n.Data, Valid: n.Valid}.Value()
+}
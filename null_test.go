@@ -0,0 +1,105 @@
+package utilities_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func TestNullPtrConverters(t *testing.T) {
+	if n := utilities.NullFromPtr[int](nil); n.Valid {
+		t.Errorf("NullFromPtr(nil) should be invalid")
+	}
+	if p := utilities.PtrFromNull(utilities.NullFromPtr(utilities.Ptr(5))); p == nil || *p != 5 {
+		t.Errorf("PtrFromNull round trip failed: %v", p)
+	}
+	if p := utilities.PtrFromNull(sql.Null[string]{}); p != nil {
+		t.Errorf("PtrFromNull(NULL) should be nil")
+	}
+
+	if ns := utilities.NullStringFromPtr(utilities.Ptr("x")); !ns.Valid || ns.String != "x" {
+		t.Errorf("NullStringFromPtr=%+v", ns)
+	}
+	if p := utilities.PtrFromNullString(sql.NullString{}); p != nil {
+		t.Errorf("PtrFromNullString(NULL) should be nil")
+	}
+	if p := utilities.PtrFromNullInt64(utilities.NullInt64FromPtr(utilities.Ptr(int64(9)))); p == nil || *p != 9 {
+		t.Errorf("NullInt64 round trip failed")
+	}
+	if p := utilities.PtrFromNullInt32(utilities.NullInt32FromPtr(nil)); p != nil {
+		t.Errorf("NullInt32 nil round trip failed")
+	}
+	if p := utilities.PtrFromNullFloat64(utilities.NullFloat64FromPtr(utilities.Ptr(1.5))); p == nil || *p != 1.5 {
+		t.Errorf("NullFloat64 round trip failed")
+	}
+	if p := utilities.PtrFromNullBool(utilities.NullBoolFromPtr(utilities.Ptr(true))); p == nil || !*p {
+		t.Errorf("NullBool round trip failed")
+	}
+	now := time.Now()
+	if p := utilities.PtrFromNullTime(utilities.NullTimeFromPtr(&now)); p == nil || !p.Equal(now) {
+		t.Errorf("NullTime round trip failed")
+	}
+}
+
+func TestNullable_JSONPatchSemantics(t *testing.T) {
+	type patch struct {
+		Name  utilities.Nullable[string] `json:"name,omitzero"`
+		Email utilities.Nullable[string] `json:"email,omitzero"`
+		Age   utilities.Nullable[int]    `json:"age,omitzero"`
+	}
+	var p patch
+	if err := json.Unmarshal([]byte(`{"name":"Ada","email":null}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Name.Present || !p.Name.Valid || p.Name.Data != "Ada" {
+		t.Errorf("name=%+v", p.Name)
+	}
+	if !p.Email.Present || p.Email.Valid {
+		t.Errorf("email should be present and null: %+v", p.Email)
+	}
+	if p.Age.Present {
+		t.Errorf("age should be absent: %+v", p.Age)
+	}
+
+	email := utilities.Ptr("old@example.com")
+	age := utilities.Ptr(40)
+	if !p.Email.ApplyTo(&email) || email != nil {
+		t.Errorf("explicit null should clear email")
+	}
+	if p.Age.ApplyTo(&age) || *age != 40 {
+		t.Errorf("absent age should leave value untouched")
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil || string(b) != `{"name":"Ada","email":null}` {
+		t.Errorf("Marshal=%s err=%v", b, err)
+	}
+	if got := p.Age.ValueOr(18); got != 18 {
+		t.Errorf("ValueOr=%d", got)
+	}
+}
+
+func TestNullable_ScanValue(t *testing.T) {
+	var n utilities.Nullable[int64]
+	if err := n.Scan(int64(7)); err != nil || !n.Valid || !n.Present || n.Data != 7 {
+		t.Errorf("Scan(7)=%+v err=%v", n, err)
+	}
+	if v, err := n.Value(); err != nil || v != int64(7) {
+		t.Errorf("Value=%v err=%v", v, err)
+	}
+	if err := n.Scan(nil); err != nil || n.Valid || !n.Present {
+		t.Errorf("Scan(nil)=%+v err=%v", n, err)
+	}
+	if v, err := n.Value(); err != nil || v != nil {
+		t.Errorf("Value of null=%v err=%v", v, err)
+	}
+	if v, _ := utilities.NullableFromPtr(utilities.Ptr("s")).Value(); v != "s" {
+		t.Errorf("NullableFromPtr Value=%v", v)
+	}
+	if utilities.NullOf[string]().Ptr() != nil || utilities.NullableOf(3).Ptr() == nil {
+		t.Errorf("Ptr mismatch")
+	}
+}
@@ -0,0 +1,346 @@
+package utilities
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor is malformed, was signed with another
+// secret, or was issued for a different sort order.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+type (
+	// SortKey is one column of a keyset ordering. Column is inserted verbatim into SQL and must be a
+	// trusted identifier; the last key should be unique (e.g. the primary key) to break ties.
+	SortKey struct {
+		Column string
+		Desc   bool
+	}
+
+	// KeysetOptions configures GormKeysetPage.
+	KeysetOptions struct {
+		// Keys is the ordering; the last key must be unique.
+		Keys []SortKey
+		// Limit is the page size; 20 when zero.
+		Limit int
+		// Secret signs cursors with HMAC-SHA256 so clients cannot tamper with them. It is required.
+		Secret []byte
+	}
+
+	// KeysetPage is one page of keyset-paginated results. NextCursor and PrevCursor are empty when
+	// there is no page in that direction.
+	KeysetPage[T any] struct {
+		Items      []T
+		NextCursor string
+		PrevCursor string
+	}
+
+	// OffsetPage is one page of offset/limit results with total counts.
+	OffsetPage[T any] struct {
+		Items      []T
+		Page       int
+		PageSize   int
+		Total      int64
+		TotalPages int
+	}
+
+	// cursorPayload is the signed cursor body: the sort keys fingerprint, direction and key values.
+	cursorPayload struct {
+		Keys   string        `json:"k"`
+		Prev   bool          `json:"p,omitempty"`
+		Values []cursorValue `json:"v"`
+	}
+
+	// cursorValue is a type-tagged cursor value so it decodes back to the original Go type.
+	cursorValue struct {
+		Type  string          `json:"t"`
+		Value json.RawMessage `json:"v"`
+	}
+)
+
+// GormKeysetPage returns one page of T using keyset (seek) pagination on db. An empty cursor
+// starts at the beginning; otherwise the cursor must come from a previous page's NextCursor or
+// PrevCursor. The cursor's key values are read from the model fields mapped to each SortKey column.
+func GormKeysetPage[T any](db *gorm.DB, opts KeysetOptions, cursor string) (KeysetPage[T], error) {
+	var page KeysetPage[T]
+	if len(opts.Keys) == 0 {
+		return page, fmt.Errorf("keyset pagination requires at least one sort key")
+	}
+	if len(opts.Secret) == 0 {
+		return page, fmt.Errorf("keyset pagination requires a cursor secret")
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var after []any
+	backward := false
+	if cursor != "" {
+		payload, err := decodeCursor(opts.Secret, cursor)
+		if err != nil {
+			return page, err
+		}
+		if payload.Keys != sortKeysFingerprint(opts.Keys) || len(payload.Values) != len(opts.Keys) {
+			return page, fmt.Errorf("%w: cursor was issued for a different ordering", ErrInvalidCursor)
+		}
+		if after, err = decodeCursorValues(payload.Values); err != nil {
+			return page, err
+		}
+		backward = payload.Prev
+	}
+
+	q := db.Session(&gorm.Session{})
+	if after != nil {
+		where, args := keysetWhere(opts.Keys, after, backward)
+		q = q.Where(where, args...)
+	}
+	for _, k := range opts.Keys {
+		q = q.Order(clause.OrderByColumn{Column: clause.Column{Name: k.Column, Raw: true}, Desc: k.Desc != backward})
+	}
+	var rows []T
+	if err := q.Limit(limit + 1).Find(&rows).Error; err != nil {
+		return page, err
+	}
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	page.Items = rows
+	if len(rows) == 0 {
+		return page, nil
+	}
+
+	sch, err := schema.Parse(new(T), schemaCache, db.NamingStrategy)
+	if err != nil {
+		return page, fmt.Errorf("failed to parse model schema: %w", err)
+	}
+	hasNext, hasPrev := more, cursor != ""
+	if backward {
+		hasNext, hasPrev = true, more
+	}
+	if hasNext {
+		if page.NextCursor, err = rowCursor(db.Statement.Context, sch, opts, rows[len(rows)-1], false); err != nil {
+			return page, err
+		}
+	}
+	if hasPrev {
+		if page.PrevCursor, err = rowCursor(db.Statement.Context, sch, opts, rows[0], true); err != nil {
+			return page, err
+		}
+	}
+	return page, nil
+}
+
+// GormOffsetPage returns page (1-based) of T with pageSize rows using OFFSET/LIMIT on db, along
+// with the total row count and page count. Ordering is taken from db.
+func GormOffsetPage[T any](db *gorm.DB, page, pageSize int) (OffsetPage[T], error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	out := OffsetPage[T]{Page: page, PageSize: pageSize}
+	if err := db.Session(&gorm.Session{}).Model(new(T)).Count(&out.Total).Error; err != nil {
+		return out, err
+	}
+	out.TotalPages = int((out.Total + int64(pageSize) - 1) / int64(pageSize))
+	if err := db.Session(&gorm.Session{}).Offset((page - 1) * pageSize).Limit(pageSize).Find(&out.Items).Error; err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// schemaCache caches parsed GORM schemas for cursor extraction.
+var schemaCache = &sync.Map{}
+
+// keysetWhere builds "((a > ?) OR (a = ? AND b > ?) ...)" honoring each key's direction.
+func keysetWhere(keys []SortKey, values []any, backward bool) (string, []any) {
+	var ors []string
+	var args []any
+	for i, k := range keys {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, keys[j].Column+" = ?")
+			args = append(args, values[j])
+		}
+		op := ">"
+		if k.Desc != backward {
+			op = "<"
+		}
+		ands = append(ands, k.Column+" "+op+" ?")
+		args = append(args, values[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+// rowCursor encodes the sort key values of row as a signed cursor.
+func rowCursor[T any](ctx context.Context, sch *schema.Schema, opts KeysetOptions, row T, prev bool) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	rv := reflect.ValueOf(&row).Elem()
+	values := make([]cursorValue, len(opts.Keys))
+	for i, k := range opts.Keys {
+		name := k.Column
+		if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+			name = name[dot+1:]
+		}
+		field := sch.LookUpField(name)
+		if field == nil {
+			return "", fmt.Errorf("sort key %q does not map to a field of %s", k.Column, sch.Name)
+		}
+		v, _ := field.ValueOf(ctx, rv)
+		cv, err := encodeCursorValue(v)
+		if err != nil {
+			return "", fmt.Errorf("sort key %q: %w", k.Column, err)
+		}
+		values[i] = cv
+	}
+	return encodeCursor(opts.Secret, cursorPayload{Keys: sortKeysFingerprint(opts.Keys), Prev: prev, Values: values})
+}
+
+// sortKeysFingerprint identifies an ordering, e.g. "created_at-,id+".
+func sortKeysFingerprint(keys []SortKey) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		dir := "+"
+		if k.Desc {
+			dir = "-"
+		}
+		parts[i] = k.Column + dir
+	}
+	return strings.Join(parts, ",")
+}
+
+// encodeCursor serializes and signs payload as base64url(json) + "." + base64url(hmac).
+func encodeCursor(secret []byte, payload cursorPayload) (string, error) {
+	if len(secret) == 0 {
+		return "", errors.New("cursor secret is empty")
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(body) + "." + enc.EncodeToString(mac.Sum(nil)), nil
+}
+
+// decodeCursor verifies the signature of cursor and decodes its payload.
+func decodeCursor(secret []byte, cursor string) (cursorPayload, error) {
+	var payload cursorPayload
+	if len(secret) == 0 {
+		return payload, fmt.Errorf("%w: cursor secret is empty", ErrInvalidCursor)
+	}
+	bodyPart, sigPart, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return payload, ErrInvalidCursor
+	}
+	enc := base64.RawURLEncoding
+	body, err := enc.DecodeString(bodyPart)
+	if err != nil {
+		return payload, ErrInvalidCursor
+	}
+	sig, err := enc.DecodeString(sigPart)
+	if err != nil {
+		return payload, ErrInvalidCursor
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return payload, fmt.Errorf("%w: signature mismatch", ErrInvalidCursor)
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return payload, ErrInvalidCursor
+	}
+	return payload, nil
+}
+
+// encodeCursorValue tags v with its kind so it can be restored with the right Go type.
+func encodeCursorValue(v any) (cursorValue, error) {
+	var typ string
+	switch val := v.(type) {
+	case time.Time:
+		typ, v = "time", val.Format(time.RFC3339Nano)
+	case string:
+		typ = "string"
+	case bool:
+		typ = "bool"
+	case int, int8, int16, int32, int64:
+		typ = "int"
+	case uint, uint8, uint16, uint32, uint64:
+		typ = "uint"
+	case float32, float64:
+		typ = "float"
+	default:
+		return cursorValue{}, fmt.Errorf("unsupported cursor value type %T", v)
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return cursorValue{}, err
+	}
+	return cursorValue{Type: typ, Value: raw}, nil
+}
+
+// decodeCursorValues restores type-tagged cursor values.
+func decodeCursorValues(in []cursorValue) ([]any, error) {
+	out := make([]any, len(in))
+	for i, cv := range in {
+		var err error
+		switch cv.Type {
+		case "time":
+			var s string
+			if err = json.Unmarshal(cv.Value, &s); err == nil {
+				out[i], err = time.Parse(time.RFC3339Nano, s)
+			}
+		case "string":
+			var s string
+			err = json.Unmarshal(cv.Value, &s)
+			out[i] = s
+		case "bool":
+			var b bool
+			err = json.Unmarshal(cv.Value, &b)
+			out[i] = b
+		case "int":
+			var n int64
+			err = json.Unmarshal(cv.Value, &n)
+			out[i] = n
+		case "uint":
+			var n uint64
+			err = json.Unmarshal(cv.Value, &n)
+			out[i] = n
+		case "float":
+			var f float64
+			err = json.Unmarshal(cv.Value, &f)
+			out[i] = f
+		default:
+			err = fmt.Errorf("unknown value type %q", cv.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,171 @@
+package utilities_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type post struct {
+	ID        int
+	Score     int
+	CreatedAt time.Time
+}
+
+func seedPosts(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&post{}); err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= 7; i++ {
+		// scores repeat so the id tiebreaker matters
+		if err := db.Create(&post{ID: i, Score: i % 3, CreatedAt: base.Add(time.Duration(i) * time.Hour)}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func postIDs(ps []post) []int {
+	ids := make([]int, len(ps))
+	for i, p := range ps {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func TestGormKeysetPage_ForwardAndBack(t *testing.T) {
+	db := seedPosts(t)
+	opts := utilities.KeysetOptions{
+		Keys:   []utilities.SortKey{{Column: "score", Desc: true}, {Column: "id"}},
+		Limit:  3,
+		Secret: []byte("s3cret"),
+	}
+	// score desc, id asc: (2:2,5) (1:1,4,7) (0:3,6)
+	want := [][]int{{2, 5, 1}, {4, 7, 3}, {6}}
+
+	var pages []utilities.KeysetPage[post]
+	cursor := ""
+	for i := 0; i < len(want); i++ {
+		page, err := utilities.GormKeysetPage[post](db, opts, cursor)
+		if err != nil {
+			t.Fatalf("page %d error: %v", i, err)
+		}
+		if got := postIDs(page.Items); !equalInts(got, want[i]) {
+			t.Fatalf("page %d ids=%v want %v", i, got, want[i])
+		}
+		pages = append(pages, page)
+		cursor = page.NextCursor
+	}
+	if pages[0].PrevCursor != "" || pages[2].NextCursor != "" {
+		t.Errorf("first page must have no prev cursor and last page no next cursor")
+	}
+
+	back, err := utilities.GormKeysetPage[post](db, opts, pages[2].PrevCursor)
+	if err != nil {
+		t.Fatalf("prev page error: %v", err)
+	}
+	if got := postIDs(back.Items); !equalInts(got, want[1]) {
+		t.Errorf("prev page ids=%v want %v", got, want[1])
+	}
+	if back.NextCursor == "" || back.PrevCursor == "" {
+		t.Errorf("middle page should have both cursors")
+	}
+}
+
+func TestGormKeysetPage_TimeKeyAndFilters(t *testing.T) {
+	db := seedPosts(t)
+	opts := utilities.KeysetOptions{Keys: []utilities.SortKey{{Column: "created_at", Desc: true}, {Column: "id", Desc: true}}, Limit: 2, Secret: []byte("s3cret")}
+	first, err := utilities.GormKeysetPage[post](db.Where("score > ?", 0), opts, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := utilities.GormKeysetPage[post](db.Where("score > ?", 0), opts, first.NextCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := append(postIDs(first.Items), postIDs(second.Items)...); !equalInts(got, []int{7, 5, 4, 2}) {
+		t.Errorf("time-ordered ids=%v", got)
+	}
+}
+
+func TestGormKeysetPage_RejectsTamperedCursor(t *testing.T) {
+	db := seedPosts(t)
+	opts := utilities.KeysetOptions{Keys: []utilities.SortKey{{Column: "id"}}, Limit: 2, Secret: []byte("k1")}
+	page, err := utilities.GormKeysetPage[post](db, opts, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := "x" + page.NextCursor[1:]
+	if _, err := utilities.GormKeysetPage[post](db, opts, tampered); !errors.Is(err, utilities.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor for tampered cursor, got %v", err)
+	}
+	other := opts
+	other.Secret = []byte("k2")
+	if _, err := utilities.GormKeysetPage[post](db, other, page.NextCursor); !errors.Is(err, utilities.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor for foreign secret, got %v", err)
+	}
+	reordered := opts
+	reordered.Keys = []utilities.SortKey{{Column: "id", Desc: true}}
+	if _, err := utilities.GormKeysetPage[post](db, reordered, page.NextCursor); err == nil || !strings.Contains(err.Error(), "different ordering") {
+		t.Errorf("expected ordering mismatch error, got %v", err)
+	}
+}
+
+func TestGormKeysetPage_RequiresSecret(t *testing.T) {
+	db := seedPosts(t)
+	opts := utilities.KeysetOptions{Keys: []utilities.SortKey{{Column: "id"}}, Limit: 2}
+	if _, err := utilities.GormKeysetPage[post](db, opts, ""); err == nil {
+		t.Error("expected error without a secret")
+	}
+
+	// a cursor signed with an empty key, as anyone could produce it
+	body := []byte(`{"k":"id","v":[{"t":"int","v":1}]}`)
+	mac := hmac.New(sha256.New, nil)
+	mac.Write(body)
+	enc := base64.RawURLEncoding
+	forged := enc.EncodeToString(body) + "." + enc.EncodeToString(mac.Sum(nil))
+	if _, err := utilities.GormKeysetPage[post](db, opts, forged); err == nil {
+		t.Error("expected forged cursor to be rejected without a secret")
+	}
+	opts.Secret = []byte("s3cret")
+	if _, err := utilities.GormKeysetPage[post](db, opts, forged); !errors.Is(err, utilities.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor for unsigned cursor, got %v", err)
+	}
+}
+
+func TestGormOffsetPage(t *testing.T) {
+	db := seedPosts(t)
+	page, err := utilities.GormOffsetPage[post](db.Order("id"), 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 7 || page.TotalPages != 3 || !equalInts(postIDs(page.Items), []int{4, 5, 6}) {
+		t.Errorf("offset page=%+v", page)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
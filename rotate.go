@@ -0,0 +1,375 @@
+package utilities
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// rotateTimeFormat is the timestamp embedded in backup file names; it sorts lexically and avoids ':'.
+const rotateTimeFormat = "2006-01-02T15-04-05.000"
+
+type (
+	// RotateOptions configures a RotatingWriter. Size and time rotation can be combined; with neither
+	// set the file only changes on Rotate or Reopen.
+	RotateOptions struct {
+		// MaxSize rotates before a write would grow the file beyond this many bytes.
+		MaxSize int64
+		// Interval rotates at every multiple of Interval since the zero time, e.g. on the hour for
+		// time.Hour and at midnight UTC for 24*time.Hour.
+		Interval time.Duration
+		// MaxBackups is the number of rotated files to keep; 0 keeps all of them.
+		MaxBackups int
+		// Compress gzips rotated files in the background.
+		Compress bool
+		// Mode is the permission of new log files; 0644 when zero.
+		Mode os.FileMode
+	}
+
+	// RotatingWriter is an io.Writer appending to a log file that is rotated by size and/or time.
+	// Rotated files are renamed to "<name>-<UTC timestamp><ext>" next to the original, optionally
+	// gzipped, and pruned to RotateOptions.MaxBackups. It is safe for concurrent use, so it can back
+	// slog handlers directly, e.g. slog.New(slog.NewJSONHandler(w, nil)).
+	RotatingWriter struct {
+		filename   string
+		opts       RotateOptions
+		mu         sync.Mutex
+		file       *os.File
+		size       int64
+		nextRotate time.Time
+		stdio      bool
+		mill       chan struct{}
+		millDone   sync.WaitGroup
+		closeOnce  sync.Once
+	}
+)
+
+// NewRotatingWriter opens (or creates) filename for appending. An existing file is rotated right
+// away when it is already over MaxSize or was last written before the current Interval began.
+func NewRotatingWriter(filename string, opts RotateOptions) (*RotatingWriter, error) {
+	if opts.Mode == 0 {
+		opts.Mode = 0644
+	}
+	w := &RotatingWriter{filename: filename, opts: opts, mill: make(chan struct{}, 1)}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory for %s: %w", filename, err)
+	}
+	if err := w.openLocked(); err != nil {
+		return nil, err
+	}
+	w.millDone.Add(1)
+	go w.millLoop()
+
+	stale := false
+	if fi, err := w.file.Stat(); err == nil && fi.Size() > 0 && opts.Interval > 0 {
+		stale = fi.ModTime().Before(time.Now().Truncate(opts.Interval))
+	}
+	if stale || (opts.MaxSize > 0 && w.size >= opts.MaxSize) {
+		w.mu.Lock()
+		err := w.rotateLocked()
+		w.mu.Unlock()
+		if err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Filename returns the path of the active log file.
+func (w *RotatingWriter) Filename() string {
+	return w.filename
+}
+
+// Write appends p to the log file, rotating first when p would exceed MaxSize or the current
+// Interval has ended. A single write larger than MaxSize is written whole to a fresh file.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	if w.shouldRotateLocked(len(p)) {
+		if err := w.rotateLocked(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	if w.stdio {
+		// output written directly to fd 1 and 2 is not seen here; the append offset includes it
+		if off, serr := w.file.Seek(0, io.SeekCurrent); serr == nil {
+			w.size = off
+		}
+	}
+	return n, err
+}
+
+// Rotate renames the current file to a backup and starts a new one.
+func (w *RotatingWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.rotateLocked()
+}
+
+// Reopen closes and reopens the log file by name, for use after an external tool such as logrotate
+// has moved it away.
+func (w *RotatingWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	return w.openLocked()
+}
+
+// ReopenOnSignal calls Reopen each time one of sigs arrives (SIGHUP when none are given), until ctx
+// is done or the returned stop function is called. This matches logrotate's "postrotate kill -HUP"
+// convention. Failures are logged with slog.
+func (w *RotatingWriter) ReopenOnSignal(ctx context.Context, sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-ch:
+				if err := w.Reopen(); err != nil {
+					slog.Error("failed to reopen log file", "signal", sig.String(), "file", w.filename, "error", err)
+				}
+			}
+		}
+	}()
+	return cancel
+}
+
+// RedirectStdio points the process's stdout and stderr file descriptors at the log file and keeps
+// them there across rotations, so output from fmt.Print, child processes and runtime panics ends
+// up in the log as well. Only supported on Linux.
+func (w *RotatingWriter) RedirectStdio() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	if err := dupStdio(w.file); err != nil {
+		return err
+	}
+	w.stdio = true
+	return nil
+}
+
+// Sync commits the log file to stable storage.
+func (w *RotatingWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.file.Sync()
+}
+
+// Close closes the log file and waits for pending compression and pruning. Redirected stdio
+// descriptors keep referring to the file until the process exits.
+func (w *RotatingWriter) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		w.mu.Lock()
+		if w.file != nil {
+			err = w.file.Close()
+			w.file = nil
+		}
+		w.mu.Unlock()
+		close(w.mill)
+		w.millDone.Wait()
+	})
+	return err
+}
+
+// Backups returns the rotated files belonging to this writer, oldest first.
+func (w *RotatingWriter) Backups() ([]string, error) {
+	dir := filepath.Dir(w.filename)
+	ext := filepath.Ext(w.filename)
+	prefix := strings.TrimSuffix(filepath.Base(w.filename), ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)[len(prefix):]
+		if _, err := time.Parse(rotateTimeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, name))
+	}
+	// the timestamp format sorts chronologically
+	sort.Strings(backups)
+	return backups, nil
+}
+
+func (w *RotatingWriter) openLocked() error {
+	f, err := os.OpenFile(w.filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, w.opts.Mode)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s: %w", w.filename, err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file, w.size = f, fi.Size()
+	if w.opts.Interval > 0 {
+		w.nextRotate = time.Now().Truncate(w.opts.Interval).Add(w.opts.Interval)
+	}
+	if w.stdio {
+		return dupStdio(f)
+	}
+	return nil
+}
+
+func (w *RotatingWriter) shouldRotateLocked(n int) bool {
+	if w.opts.MaxSize > 0 && w.size > 0 && w.size+int64(n) > w.opts.MaxSize {
+		return true
+	}
+	if w.nextRotate.IsZero() || time.Now().Before(w.nextRotate) {
+		return false
+	}
+	if w.size == 0 {
+		// nothing was logged this interval; skip the empty backup
+		w.nextRotate = time.Now().Truncate(w.opts.Interval).Add(w.opts.Interval)
+		return false
+	}
+	return true
+}
+
+func (w *RotatingWriter) rotateLocked() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+	ext := filepath.Ext(w.filename)
+	base := strings.TrimSuffix(w.filename, ext)
+	now := time.Now().UTC()
+	backup := base + "-" + now.Format(rotateTimeFormat) + ext
+	for {
+		if _, err := os.Lstat(backup); errors.Is(err, os.ErrNotExist) {
+			if _, err := os.Lstat(backup + ".gz"); errors.Is(err, os.ErrNotExist) {
+				break
+			}
+		}
+		now = now.Add(time.Millisecond)
+		backup = base + "-" + now.Format(rotateTimeFormat) + ext
+	}
+	if err := os.Rename(w.filename, backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		// keep logging to the old file rather than losing output
+		if oerr := w.openLocked(); oerr != nil {
+			return errors.Join(err, oerr)
+		}
+		return fmt.Errorf("failed to rotate %s: %w", w.filename, err)
+	}
+	if err := w.openLocked(); err != nil {
+		return err
+	}
+	select {
+	case w.mill <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// millLoop compresses and prunes backups after rotations, off the write path.
+func (w *RotatingWriter) millLoop() {
+	defer w.millDone.Done()
+	for range w.mill {
+		if err := w.millBackups(); err != nil {
+			slog.Warn("failed to process rotated log files", "file", w.filename, "error", err)
+		}
+	}
+}
+
+func (w *RotatingWriter) millBackups() error {
+	backups, err := w.Backups()
+	if err != nil {
+		return err
+	}
+	if w.opts.MaxBackups > 0 && len(backups) > w.opts.MaxBackups {
+		for _, old := range backups[:len(backups)-w.opts.MaxBackups] {
+			if err := os.Remove(old); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		backups = backups[len(backups)-w.opts.MaxBackups:]
+	}
+	if !w.opts.Compress {
+		return nil
+	}
+	for _, b := range backups {
+		if strings.HasSuffix(b, ".gz") {
+			continue
+		}
+		if err := gzipFile(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gzipFile replaces name with name.gz, keeping its permissions and modification time.
+func gzipFile(name string) error {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	af, err := CreateAtomic(name+".gz", fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer af.Abort()
+	gz := gzip.NewWriter(af)
+	gz.Name = filepath.Base(name)
+	gz.ModTime = fi.ModTime()
+	if _, err := io.Copy(gz, in); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := af.Commit(); err != nil {
+		return err
+	}
+	_ = os.Chtimes(name+".gz", fi.ModTime(), fi.ModTime())
+	return os.Remove(name)
+}
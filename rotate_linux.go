@@ -0,0 +1,20 @@
+//go:build linux
+
+package utilities
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// dupStdio makes file descriptors 1 and 2 refer to f.
+func dupStdio(f *os.File) error {
+	for _, fd := range []int{1, 2} {
+		if err := unix.Dup3(int(f.Fd()), fd, 0); err != nil {
+			return fmt.Errorf("failed to redirect fd %d to %s: %w", fd, f.Name(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package utilities
+
+import (
+	"errors"
+	"os"
+)
+
+func dupStdio(*os.File) error {
+	return errors.New("redirecting stdio is only supported on Linux")
+}
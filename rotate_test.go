@@ -0,0 +1,124 @@
+package utilities_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func TestRotatingWriter_SizeRotationCompressAndPrune(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	w, err := utilities.NewRotatingWriter(name, utilities.RotateOptions{MaxSize: 100, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := strings.Repeat("x", 39) + "\n"
+	for i := 0; i < 10; i++ {
+		if _, err := io.WriteString(w, line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("late")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("write after close err=%v", err)
+	}
+	fi, err := os.Stat(name)
+	if err != nil || fi.Size() > 100 {
+		t.Fatalf("active file size=%v err=%v", fi, err)
+	}
+	backups, err := w.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("backups=%v", backups)
+	}
+	for _, b := range backups {
+		if !strings.HasSuffix(b, ".log.gz") {
+			t.Errorf("backup %s not compressed", b)
+			continue
+		}
+		f, _ := os.Open(b)
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(gz)
+		f.Close()
+		if string(data) != line+line {
+			t.Errorf("%s contents=%q", b, data)
+		}
+	}
+}
+
+func TestRotatingWriter_IntervalAndExistingFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(name, bytes.Repeat([]byte("y"), 200), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := utilities.NewRotatingWriter(name, utilities.RotateOptions{MaxSize: 100, Interval: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if backups, _ := w.Backups(); len(backups) != 1 {
+		t.Fatalf("oversized existing file not rotated on open: %v", backups)
+	}
+	_, _ = w.Write([]byte("first\n"))
+	time.Sleep(250 * time.Millisecond)
+	_, _ = w.Write([]byte("second\n"))
+	if backups, _ := w.Backups(); len(backups) != 2 {
+		t.Fatalf("interval rotation did not happen: %v", backups)
+	}
+	if data, _ := os.ReadFile(name); string(data) != "second\n" {
+		t.Errorf("active file=%q", data)
+	}
+}
+
+func TestRotatingWriter_RedirectStdio(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("stdio redirection is Linux-only")
+	}
+	if name := os.Getenv("ROTATE_STDIO_HELPER"); name != "" {
+		w, err := utilities.NewRotatingWriter(name, utilities.RotateOptions{})
+		if err != nil {
+			os.Exit(2)
+		}
+		if err := w.RedirectStdio(); err != nil {
+			os.Exit(3)
+		}
+		fmt.Println("to stdout")
+		_ = w.Rotate()
+		fmt.Fprintln(os.Stderr, "to stderr after rotate")
+		os.Exit(0)
+	}
+	name := filepath.Join(t.TempDir(), "daemon.log")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRotatingWriter_RedirectStdio$")
+	cmd.Env = append(os.Environ(), "ROTATE_STDIO_HELPER="+name)
+	if out, err := cmd.CombinedOutput(); err != nil || len(out) != 0 {
+		t.Fatalf("helper err=%v output=%q", err, out)
+	}
+	if data, _ := os.ReadFile(name); string(data) != "to stderr after rotate\n" {
+		t.Errorf("active log=%q", data)
+	}
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(name), "daemon-*.log"))
+	if len(matches) != 1 {
+		t.Fatalf("backups=%v", matches)
+	}
+	if data, _ := os.ReadFile(matches[0]); string(data) != "to stdout\n" {
+		t.Errorf("backup=%q", data)
+	}
+}
@@ -0,0 +1,58 @@
+//go:build unix
+
+package utilities_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func TestRotatingWriter_ReopenOnSignalAndSlog(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	w, err := utilities.NewRotatingWriter(name, utilities.RotateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	logger := slog.New(slog.NewTextHandler(w, nil))
+	logger.Info("before rotate")
+
+	stop := w.ReopenOnSignal(context.Background())
+	defer stop()
+	// what logrotate does before sending SIGHUP
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(name); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("log file was not reopened after SIGHUP")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	logger.Info("after rotate")
+
+	old, _ := os.ReadFile(name + ".1")
+	cur, _ := os.ReadFile(name)
+	if !strings.Contains(string(old), "before rotate") || strings.Contains(string(old), "after rotate") {
+		t.Errorf("old file=%q", old)
+	}
+	if !strings.Contains(string(cur), "after rotate") {
+		t.Errorf("new file=%q", cur)
+	}
+}
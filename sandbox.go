@@ -0,0 +1,181 @@
+package utilities
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrPathEscapes is returned for user-supplied names that are absolute, contain ".." elements or
+// NUL bytes, or resolve through a symlink to a location outside the sandbox.
+var ErrPathEscapes = errors.New("path escapes sandbox")
+
+// Sandbox resolves and opens user-supplied relative paths strictly below a base directory. Files are
+// opened through an os.Root, so symlinks swapped in after validation cannot escape either.
+type Sandbox struct {
+	base string
+	root *os.Root
+}
+
+// NewSandbox returns a Sandbox rooted at base, which must be an existing directory.
+func NewSandbox(base string) (*Sandbox, error) {
+	abs, err := filepath.Abs(base)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	root, err := os.OpenRoot(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sandbox root %s: %w", base, err)
+	}
+	return &Sandbox{base: abs, root: root}, nil
+}
+
+// Base returns the absolute, symlink-resolved base directory.
+func (s *Sandbox) Base() string {
+	return s.base
+}
+
+// Close releases the sandbox root.
+func (s *Sandbox) Close() error {
+	return s.root.Close()
+}
+
+// Clean validates a user-supplied name and returns it as a clean slash-separated relative path.
+// Both "/" and "\" are treated as separators; empty names resolve to ".".
+func (s *Sandbox) Clean(name string) (string, error) {
+	if strings.IndexByte(name, 0) >= 0 {
+		return "", fmt.Errorf("%w: %q contains a NUL byte", ErrPathEscapes, name)
+	}
+	slashed := strings.ReplaceAll(name, `\`, "/")
+	if strings.HasPrefix(slashed, "/") || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("%w: %q is absolute", ErrPathEscapes, name)
+	}
+	for _, elem := range strings.Split(slashed, "/") {
+		if elem == ".." {
+			return "", fmt.Errorf("%w: %q contains ..", ErrPathEscapes, name)
+		}
+	}
+	cleaned := strings.TrimPrefix(path.Clean("/"+slashed), "/")
+	if cleaned == "" {
+		cleaned = "."
+	}
+	return cleaned, nil
+}
+
+// Resolve validates name and returns the absolute path it refers to below the base directory.
+// Existing symlinks along the path are followed and must stay inside the sandbox; a missing final
+// element is allowed so the result can be used to create files, but a dangling symlink is rejected
+// since writing through it would create its target. The returned path can still be swapped for a
+// symlink afterwards; prefer OpenFile for creating files.
+func (s *Sandbox) Resolve(name string) (string, error) {
+	rel, err := s.Clean(name)
+	if err != nil {
+		return "", err
+	}
+	full := filepath.Join(s.base, filepath.FromSlash(rel))
+	resolved, err := filepath.EvalSymlinks(full)
+	if errors.Is(err, fs.ErrNotExist) {
+		if fi, lerr := os.Lstat(full); lerr == nil && fi.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("%w: %q is a dangling symlink", ErrPathEscapes, name)
+		}
+		// resolve the existing parent; the last element is created later
+		parent, perr := filepath.EvalSymlinks(filepath.Dir(full))
+		if perr != nil {
+			return "", perr
+		}
+		resolved = filepath.Join(parent, filepath.Base(full))
+	} else if err != nil {
+		return "", err
+	}
+	if !pathWithin(resolved, s.base) {
+		return "", fmt.Errorf("%w: %q resolves outside %s", ErrPathEscapes, name, s.base)
+	}
+	return full, nil
+}
+
+// Open opens name for reading inside the sandbox. Symlinks are followed only while they stay inside
+// the base directory; absolute symlinks are always treated as escapes.
+func (s *Sandbox) Open(name string) (*os.File, error) {
+	rel, err := s.Clean(name)
+	if err != nil {
+		return nil, err
+	}
+	return openInRoot(s.root, rel, name)
+}
+
+// OpenFile opens name inside the sandbox with the given flags and permission, like os.OpenFile.
+// It goes through the sandbox's os.Root, so creating a file through a symlink that points outside
+// the base directory fails with ErrPathEscapes.
+func (s *Sandbox) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	rel, err := s.Clean(name)
+	if err != nil {
+		return nil, err
+	}
+	return openFileInRoot(s.root, rel, name, flag, perm)
+}
+
+// Create creates or truncates name inside the sandbox, like os.Create.
+func (s *Sandbox) Create(name string) (*os.File, error) {
+	return s.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// openInRoot opens rel inside root, reporting symlink escapes as ErrPathEscapes for name.
+func openInRoot(root *os.Root, rel, name string) (*os.File, error) {
+	return openFileInRoot(root, rel, name, os.O_RDONLY, 0)
+}
+
+// openFileInRoot is openInRoot with explicit flags and permission.
+func openFileInRoot(root *os.Root, rel, name string, flag int, perm os.FileMode) (*os.File, error) {
+	f, err := root.OpenFile(rel, flag, perm)
+	// os.Root does not export its escape error, so match its message
+	if err != nil && strings.Contains(err.Error(), "escapes from parent") {
+		return nil, fmt.Errorf("%w: %q", ErrPathEscapes, name)
+	}
+	return f, err
+}
+
+// ServeFile serves the regular file name from the sandbox with http.ServeContent, using
+// MimeTypeFromExtension for the Content-Type and disabling browser content sniffing. Nothing is
+// written on error: ErrPathEscapes for invalid names, fs.ErrNotExist for missing files and
+// directories, so callers can choose the status code (see SandboxHTTPStatus).
+func (s *Sandbox) ServeFile(w http.ResponseWriter, r *http.Request, name string) error {
+	f, err := s.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file: %w", name, fs.ErrNotExist)
+	}
+	w.Header().Set("Content-Type", MimeTypeFromExtension(fi.Name()))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, fi.Name(), fi.ModTime(), f)
+	return nil
+}
+
+// SandboxHTTPStatus maps a Sandbox error to an HTTP status: 400 for ErrPathEscapes, 404 for missing
+// files, 403 for permission errors and 500 otherwise.
+func SandboxHTTPStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrPathEscapes):
+		return http.StatusBadRequest
+	case errors.Is(err, fs.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
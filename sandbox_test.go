@@ -0,0 +1,112 @@
+package utilities_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func newTestSandbox(t *testing.T) (*utilities.Sandbox, string) {
+	t.Helper()
+	base := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(base, "docs"), 0755)
+	_ = os.WriteFile(filepath.Join(base, "docs", "a.pdf"), []byte("%PDF-1.4"), 0644)
+	outside := t.TempDir()
+	_ = os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+	_ = os.Symlink(outside, filepath.Join(base, "escape"))
+	_ = os.Symlink("docs", filepath.Join(base, "inside"))
+	sb, err := utilities.NewSandbox(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sb.Close() })
+	return sb, base
+}
+
+func TestSandbox_RejectsEscapes(t *testing.T) {
+	sb, _ := newTestSandbox(t)
+	for _, name := range []string{"../etc/passwd", "docs/../../x", `docs\..\..\x`, "/etc/passwd", `\etc\passwd`, "a\x00b", "escape/secret.txt"} {
+		if _, err := sb.Open(name); !errors.Is(err, utilities.ErrPathEscapes) {
+			t.Errorf("Open(%q) err=%v, want ErrPathEscapes", name, err)
+		}
+		if _, err := sb.Resolve(name); !errors.Is(err, utilities.ErrPathEscapes) {
+			t.Errorf("Resolve(%q) err=%v, want ErrPathEscapes", name, err)
+		}
+	}
+}
+
+func TestSandbox_OpenAndResolve(t *testing.T) {
+	sb, base := newTestSandbox(t)
+	f, err := sb.Open("./docs//a.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if string(data) != "%PDF-1.4" {
+		t.Errorf("data=%q", data)
+	}
+	if _, err := sb.Open("inside/a.pdf"); err != nil {
+		t.Errorf("relative symlink inside the sandbox should be allowed: %v", err)
+	}
+	if p, err := sb.Resolve("docs/new.txt"); err != nil || p != filepath.Join(sb.Base(), "docs", "new.txt") {
+		t.Errorf("Resolve=%q err=%v (base %s)", p, err, base)
+	}
+	if _, err := sb.Open("docs/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file err=%v", err)
+	}
+}
+
+func TestSandbox_DanglingSymlinkAndCreate(t *testing.T) {
+	sb, base := newTestSandbox(t)
+	outside := t.TempDir()
+	if err := os.Symlink(filepath.Join(outside, "pwned.txt"), filepath.Join(base, "evil")); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := sb.Resolve("evil"); !errors.Is(err, utilities.ErrPathEscapes) {
+		t.Errorf("Resolve of dangling symlink=%q err=%v, want ErrPathEscapes", p, err)
+	}
+	if f, err := sb.Create("evil"); !errors.Is(err, utilities.ErrPathEscapes) {
+		if f != nil {
+			f.Close()
+		}
+		t.Errorf("Create through dangling symlink err=%v, want ErrPathEscapes", err)
+	}
+	if _, err := os.Lstat(filepath.Join(outside, "pwned.txt")); err == nil {
+		t.Error("file created outside the sandbox")
+	}
+
+	f, err := sb.Create("docs/new.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("ok")
+	f.Close()
+	if data, _ := os.ReadFile(filepath.Join(base, "docs", "new.txt")); string(data) != "ok" {
+		t.Errorf("created file=%q", data)
+	}
+}
+
+func TestSandbox_ServeFile(t *testing.T) {
+	sb, _ := newTestSandbox(t)
+	w := httptest.NewRecorder()
+	if err := sb.ServeFile(w, httptest.NewRequest("GET", "/", nil), "docs/a.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != 200 || w.Header().Get("Content-Type") != "application/pdf" || w.Header().Get("X-Content-Type-Options") != "nosniff" || w.Body.String() != "%PDF-1.4" {
+		t.Errorf("code=%d headers=%v body=%q", w.Code, w.Header(), w.Body.String())
+	}
+	cases := map[string]int{"docs": 404, "docs/nope.pdf": 404, "../x": 400}
+	for name, want := range cases {
+		err := sb.ServeFile(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), name)
+		if got := utilities.SandboxHTTPStatus(err); got != want {
+			t.Errorf("%s: status=%d want %d (err=%v)", name, got, want, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package utilities
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+	"gorm.io/gorm"
+)
+
+// SQLTableOptions controls how WriteSQLRows renders a result set.
+type SQLTableOptions struct {
+	// Writer receives the table; os.Stdout when nil.
+	Writer io.Writer
+	// Null is printed for SQL NULL values; "NULL" when empty.
+	Null string
+	// ShowTypes appends each column's database type to its header, e.g. "id:INT8".
+	ShowTypes bool
+	// Stream renders rows as they are read instead of buffering the whole result. Column widths
+	// are fixed from the header, so long values wrap; use it for large results.
+	Stream bool
+	// MaxRows stops after this many rows when greater than zero.
+	MaxRows int
+}
+
+// PrintSQLRows prints a *sql.Rows result set as a table to stdout, preserving the query's column
+// order and rendering NULLs as "NULL". The rows are consumed and closed.
+func PrintSQLRows(rows *sql.Rows) error {
+	return WriteSQLRows(rows, SQLTableOptions{})
+}
+
+// PrintGormRows runs the query built on db (e.g. db.Table("users").Select("id, name")) and prints
+// its result set as a table to stdout.
+func PrintGormRows(db *gorm.DB) error {
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	return PrintSQLRows(rows)
+}
+
+// WriteSQLRows renders a *sql.Rows result set as a table according to opts. Values are shown as
+// returned by the driver: []byte as text (hex when not valid UTF-8), time.Time in RFC 3339 and
+// NULL as opts.Null. The rows are consumed and closed.
+func WriteSQLRows(rows *sql.Rows, opts SQLTableOptions) (err error) {
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+	w := opts.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	null := opts.Null
+	if null == "" {
+		null = "NULL"
+	}
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	header := make([]string, len(colTypes))
+	for i, ct := range colTypes {
+		header[i] = ct.Name()
+		if opts.ShowTypes && ct.DatabaseTypeName() != "" {
+			header[i] = ct.Name() + ":" + ct.DatabaseTypeName()
+		}
+	}
+
+	var table *tablewriter.Table
+	if opts.Stream {
+		table = tablewriter.NewTable(w, tablewriter.WithStreaming(tw.StreamConfig{Enable: true}))
+		if err := table.Start(); err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := table.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}()
+	} else {
+		table = tablewriter.NewWriter(w)
+	}
+	table.Header(header)
+
+	values := make([]any, len(colTypes))
+	dest := make([]any, len(colTypes))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	count := 0
+	for rows.Next() {
+		if opts.MaxRows > 0 && count >= opts.MaxRows {
+			break
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = formatSQLValue(v, null)
+		}
+		if err := table.Append(cells); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if opts.Stream {
+		return nil
+	}
+	return table.Render()
+}
+
+// formatSQLValue renders a scanned driver value for display.
+func formatSQLValue(v any, null string) string {
+	switch val := v.(type) {
+	case nil:
+		return null
+	case []byte:
+		if utf8.Valid(val) {
+			return string(val)
+		}
+		return fmt.Sprintf("0x%x", val)
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
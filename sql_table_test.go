@@ -0,0 +1,92 @@
+package utilities_test
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func seededSQLDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+	for _, stmt := range []string{
+		"CREATE TABLE people (zeta TEXT, alpha INTEGER, note TEXT)",
+		"INSERT INTO people VALUES ('z1', 1, NULL), ('z2', 2, 'hello')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestWriteSQLRows(t *testing.T) {
+	db := seededSQLDB(t)
+	for _, stream := range []bool{false, true} {
+		rows, err := db.Query("SELECT zeta, alpha, note FROM people ORDER BY alpha")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := utilities.WriteSQLRows(rows, utilities.SQLTableOptions{Writer: &buf, Stream: stream, Null: "<null>", ShowTypes: true}); err != nil {
+			t.Fatalf("stream=%v WriteSQLRows error: %v", stream, err)
+		}
+		out := buf.String()
+		// column order follows the query, not alphabetical order
+		if z, a := strings.Index(out, "ZETA"), strings.Index(out, "ALPHA"); z < 0 || a < 0 || z > a {
+			t.Errorf("stream=%v column order not preserved:\n%s", stream, out)
+		}
+		if !strings.Contains(out, "<null>") || !strings.Contains(out, "hello") || !strings.Contains(out, "INTEGER") {
+			t.Errorf("stream=%v unexpected output:\n%s", stream, out)
+		}
+	}
+
+	rows, err := db.Query("SELECT alpha FROM people ORDER BY alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := utilities.WriteSQLRows(rows, utilities.SQLTableOptions{Writer: &buf, MaxRows: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "2") {
+		t.Errorf("MaxRows not honored:\n%s", buf.String())
+	}
+}
+
+func TestPrintSQLRowsAndGormRows(t *testing.T) {
+	db := seededSQLDB(t)
+	rows, err := db.Query("SELECT * FROM people")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := captureStdout(func() error { return utilities.PrintSQLRows(rows) })
+	if err != nil || !strings.Contains(out, "NULL") {
+		t.Errorf("PrintSQLRows output=%q err=%v", out, err)
+	}
+
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.Exec("CREATE TABLE things (id INTEGER, label TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.Exec("INSERT INTO things VALUES (1, 'widget')").Error; err != nil {
+		t.Fatal(err)
+	}
+	out, err = captureStdout(func() error { return utilities.PrintGormRows(gdb.Table("things").Select("label, id")) })
+	if err != nil || !strings.Contains(out, "widget") {
+		t.Errorf("PrintGormRows output=%q err=%v", out, err)
+	}
+}
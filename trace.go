@@ -0,0 +1,77 @@
+package utilities
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+// TraceEnvVar names the environment variable that enables Trace at startup when set to a true
+// value ("1", "true"). Tracing is off by default; building with the notrace tag removes it entirely.
+const TraceEnvVar = "GO_UTILITIES_TRACE"
+
+// TraceLevel is the slog level of trace records.
+const TraceLevel = slog.LevelDebug
+
+// CallSite identifies a location in the program.
+type CallSite struct {
+	Function string
+	File     string
+	Line     int
+}
+
+var (
+	traceOn     atomic.Bool
+	traceLogger atomic.Pointer[slog.Logger]
+)
+
+func init() {
+	if v, ok := os.LookupEnv(TraceEnvVar); ok {
+		on, _ := strconv.ParseBool(v)
+		traceOn.Store(on)
+	}
+}
+
+// Caller returns the call site skip frames up the stack, like runtime.Caller: 0 identifies the
+// function calling Caller, 1 its caller, and so on.
+func Caller(skip int) CallSite {
+	pc, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return CallSite{}
+	}
+	return callSiteFromPC(pc, file, line)
+}
+
+// String formats the call site as "file.go:42 (pkg.Func)".
+func (c CallSite) String() string {
+	return fmt.Sprintf("%s:%d (%s)", filepath.Base(c.File), c.Line, c.Function)
+}
+
+// SetTraceEnabled turns tracing on or off at runtime. It has no effect in notrace builds.
+func SetTraceEnabled(on bool) {
+	traceOn.Store(on)
+}
+
+// SetTraceLogger sets the logger receiving trace records; nil restores slog.Default().
+func SetTraceLogger(l *slog.Logger) {
+	traceLogger.Store(l)
+}
+
+func callSiteFromPC(pc uintptr, file string, line int) CallSite {
+	cs := CallSite{File: file, Line: line}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		cs.Function = fn.Name()
+	}
+	return cs
+}
+
+func currentTraceLogger() *slog.Logger {
+	if l := traceLogger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
@@ -0,0 +1,15 @@
+//go:build notrace
+
+package utilities
+
+// TraceEnabled reports whether Trace currently logs; always false in notrace builds.
+func TraceEnabled() bool {
+	return false
+}
+
+// Trace is a no-op in notrace builds; see the default build for details.
+func Trace(string, ...any) func(...any) {
+	return traceNoop
+}
+
+func traceNoop(...any) {}
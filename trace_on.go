@@ -0,0 +1,61 @@
+//go:build !notrace
+
+package utilities
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// TraceEnabled reports whether Trace currently logs.
+func TraceEnabled() bool {
+	return traceOn.Load()
+}
+
+// Trace logs an entry record for name with args (slog-style key/value pairs) and returns a function
+// that logs the matching exit record with the elapsed time and any results, grouped by position:
+//
+//	defer Trace("loadUser", "id", id)()
+//
+// Records are logged at TraceLevel with the caller's function, file and line as their source. When
+// tracing is disabled, Trace returns a shared no-op without inspecting the stack.
+func Trace(name string, args ...any) func(results ...any) {
+	if !traceOn.Load() {
+		return traceNoop
+	}
+	logger := currentTraceLogger()
+	ctx := context.Background()
+	if !logger.Enabled(ctx, TraceLevel) {
+		return traceNoop
+	}
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	site := CallSite{Function: frame.Function, File: frame.File, Line: frame.Line}
+
+	start := time.Now()
+	enter := slog.NewRecord(start, TraceLevel, "trace enter", pcs[0])
+	enter.AddAttrs(slog.String("trace", name), slog.String("caller", site.String()))
+	if len(args) > 0 {
+		enter.AddAttrs(slog.Group("args", args...))
+	}
+	_ = logger.Handler().Handle(ctx, enter)
+
+	return func(results ...any) {
+		exit := slog.NewRecord(time.Now(), TraceLevel, "trace exit", pcs[0])
+		exit.AddAttrs(slog.String("trace", name), slog.String("caller", site.String()), slog.Duration("elapsed", time.Since(start)))
+		if len(results) > 0 {
+			attrs := make([]any, len(results))
+			for i, r := range results {
+				attrs[i] = slog.Any(strconv.Itoa(i), r)
+			}
+			exit.AddAttrs(slog.Group("results", attrs...))
+		}
+		_ = logger.Handler().Handle(ctx, exit)
+	}
+}
+
+func traceNoop(...any) {}
@@ -0,0 +1,80 @@
+package utilities_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func loadUserTraced(id int) (string, error) {
+	var name string
+	var err error
+	end := utilities.Trace("loadUser", "id", id)
+	defer func() { end(name, err) }()
+	name, err = "ada", errors.New("stale cache")
+	return name, err
+}
+
+func TestTrace_EntryAndExitRecords(t *testing.T) {
+	var buf bytes.Buffer
+	utilities.SetTraceLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug, AddSource: true})))
+	utilities.SetTraceEnabled(true)
+	t.Cleanup(func() {
+		utilities.SetTraceEnabled(false)
+		utilities.SetTraceLogger(nil)
+	})
+	if !utilities.TraceEnabled() {
+		t.Skip("built with notrace")
+	}
+
+	if _, err := loadUserTraced(42); err == nil {
+		t.Fatal("expected error")
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records, got %q", buf.String())
+	}
+	var enter, exit map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &enter); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &exit); err != nil {
+		t.Fatal(err)
+	}
+	if enter["msg"] != "trace enter" || enter["trace"] != "loadUser" || enter["args"].(map[string]any)["id"] != 42.0 {
+		t.Errorf("enter=%v", enter)
+	}
+	if caller, _ := enter["caller"].(string); !strings.Contains(caller, "trace_test.go:") || !strings.Contains(caller, "loadUserTraced") {
+		t.Errorf("caller=%v", enter["caller"])
+	}
+	if src, _ := enter["source"].(map[string]any); src == nil || !strings.HasSuffix(src["function"].(string), "loadUserTraced") {
+		t.Errorf("source=%v", enter["source"])
+	}
+	results, _ := exit["results"].(map[string]any)
+	if exit["msg"] != "trace exit" || exit["elapsed"] == nil || results["0"] != "ada" || results["1"] != "stale cache" {
+		t.Errorf("exit=%v", exit)
+	}
+}
+
+func TestTrace_DisabledIsSilent(t *testing.T) {
+	var buf bytes.Buffer
+	utilities.SetTraceLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { utilities.SetTraceLogger(nil) })
+	utilities.SetTraceEnabled(false)
+	utilities.Trace("quiet", "k", 1)("r")
+	if buf.Len() != 0 || utilities.TraceEnabled() {
+		t.Errorf("disabled trace logged %q", buf.String())
+	}
+}
+
+func TestCaller(t *testing.T) {
+	cs := utilities.Caller(0)
+	if !strings.HasSuffix(cs.Function, "TestCaller") || !strings.HasSuffix(cs.File, "trace_test.go") || cs.Line == 0 {
+		t.Errorf("Caller(0)=%+v", cs)
+	}
+}
@@ -0,0 +1,269 @@
+package utilities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WatchOp is a bit set describing what happened to a watched path.
+type WatchOp uint32
+
+const (
+	// WatchCreate reports a new file, including one renamed into place by an atomic replace.
+	WatchCreate WatchOp = 1 << iota
+	// WatchWrite reports modified contents.
+	WatchWrite
+	// WatchRemove reports a deleted file.
+	WatchRemove
+	// WatchRename reports a file renamed away.
+	WatchRename
+	// WatchChmod reports changed metadata.
+	WatchChmod
+)
+
+// ErrWatchOverflow is sent on Watcher.Errors when the kernel dropped events; rescan watched paths.
+var ErrWatchOverflow = errors.New("file watch event queue overflowed")
+
+type (
+	// WatchEvent is a debounced change to one path; Op combines everything seen during the window.
+	WatchEvent struct {
+		Path string
+		Op   WatchOp
+	}
+
+	// WatchOptions configures a Watcher.
+	WatchOptions struct {
+		// Debounce is how long a path must be quiet before its events are delivered; 100ms when zero.
+		Debounce time.Duration
+	}
+
+	// Watcher delivers debounced file and directory change notifications. Files are watched through
+	// their parent directory, so editors that save by writing a temporary file and renaming it over
+	// the original (and WriteFileAtomic) keep being tracked.
+	Watcher struct {
+		events chan []WatchEvent
+		errors chan error
+		raw    chan WatchEvent
+		done   chan struct{}
+		closed sync.Once
+		wg     sync.WaitGroup
+		impl   watcherImpl
+	}
+
+	// watcherImpl is the platform-specific event source feeding Watcher.emit.
+	watcherImpl interface {
+		add(path string) error
+		close() error
+	}
+
+	// JSONFileWatcher keeps the decoded contents of a JSON file current; see WatchJSONFile.
+	JSONFileWatcher[T any] struct {
+		mu      sync.RWMutex
+		current T
+		watcher *Watcher
+	}
+)
+
+// String lists the set operations, e.g. "CREATE|WRITE".
+func (op WatchOp) String() string {
+	var parts []string
+	for _, p := range []struct {
+		op   WatchOp
+		name string
+	}{{WatchCreate, "CREATE"}, {WatchWrite, "WRITE"}, {WatchRemove, "REMOVE"}, {WatchRename, "RENAME"}, {WatchChmod, "CHMOD"}} {
+		if op&p.op != 0 {
+			parts = append(parts, p.name)
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+// Has reports whether op includes all bits of other.
+func (op WatchOp) Has(other WatchOp) bool {
+	return op&other == other
+}
+
+// NewWatcher starts a watcher with no paths; add them with Add. Watching uses inotify and is only
+// available on Linux.
+func NewWatcher(opts WatchOptions) (*Watcher, error) {
+	debounce := opts.Debounce
+	if debounce <= 0 {
+		debounce = 100 * time.Millisecond
+	}
+	w := &Watcher{
+		events: make(chan []WatchEvent, 16),
+		errors: make(chan error, 16),
+		raw:    make(chan WatchEvent, 256),
+		done:   make(chan struct{}),
+	}
+	impl, err := newWatcherImpl(w)
+	if err != nil {
+		return nil, err
+	}
+	w.impl = impl
+	w.wg.Add(1)
+	go w.debounceLoop(debounce)
+	return w, nil
+}
+
+// Add watches path: for a directory, changes to its direct entries; for a file, changes to that
+// file, which need not exist yet as long as its directory does.
+func (w *Watcher) Add(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	return w.impl.add(abs)
+}
+
+// Events delivers batches of debounced events sorted by path. It is closed by Close.
+func (w *Watcher) Events() <-chan []WatchEvent {
+	return w.events
+}
+
+// Errors delivers watch errors such as ErrWatchOverflow. It is closed by Close.
+func (w *Watcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Close stops watching and closes the Events and Errors channels.
+func (w *Watcher) Close() error {
+	var err error
+	w.closed.Do(func() {
+		close(w.done)
+		err = w.impl.close()
+		w.wg.Wait()
+		close(w.events)
+		close(w.errors)
+	})
+	return err
+}
+
+// emit queues a raw event from the platform implementation.
+func (w *Watcher) emit(ev WatchEvent) {
+	select {
+	case w.raw <- ev:
+	case <-w.done:
+	}
+}
+
+// emitError reports an error without blocking when nobody is reading Errors.
+func (w *Watcher) emitError(err error) {
+	select {
+	case w.errors <- err:
+	case <-w.done:
+	default:
+	}
+}
+
+// debounceLoop merges raw events per path and flushes them once no event arrived for debounce.
+func (w *Watcher) debounceLoop(debounce time.Duration) {
+	defer w.wg.Done()
+	pending := map[string]WatchOp{}
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-w.done:
+			timer.Stop()
+			return
+		case ev := <-w.raw:
+			pending[ev.Path] |= ev.Op
+			timer.Reset(debounce)
+		case <-timer.C:
+			batch := make([]WatchEvent, 0, len(pending))
+			for p, op := range pending {
+				batch = append(batch, WatchEvent{Path: p, Op: op})
+			}
+			sort.Slice(batch, func(i, j int) bool { return batch[i].Path < batch[j].Path })
+			pending = map[string]WatchOp{}
+			select {
+			case w.events <- batch:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// WatchJSONFile decodes filename into a T with FromJSONFile and keeps it current: after each
+// debounced change the file is decoded again and, when the result differs, onChange receives the
+// old and new values. Unreadable or invalid contents (e.g. a half-written file) are logged with
+// slog and the previous value is kept. Watching stops when ctx is done or Close is called.
+func WatchJSONFile[T any](ctx context.Context, filename string, opts WatchOptions, onChange func(old, new T)) (*JSONFileWatcher[T], error) {
+	jw := &JSONFileWatcher[T]{}
+	if err := FromJSONFile(filename, &jw.current); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", filename, err)
+	}
+	w, err := NewWatcher(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Add(filename); err != nil {
+		w.Close()
+		return nil, err
+	}
+	jw.watcher = w
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				w.Close()
+				return
+			case err, ok := <-w.Errors():
+				if !ok {
+					return
+				}
+				slog.Warn("config watch error", "file", filename, "error", err)
+			case _, ok := <-w.Events():
+				if !ok {
+					return
+				}
+				jw.reload(filename, onChange)
+			}
+		}
+	}()
+	return jw, nil
+}
+
+// Current returns the most recently decoded value.
+func (jw *JSONFileWatcher[T]) Current() T {
+	jw.mu.RLock()
+	defer jw.mu.RUnlock()
+	return jw.current
+}
+
+// Close stops watching the file.
+func (jw *JSONFileWatcher[T]) Close() error {
+	return jw.watcher.Close()
+}
+
+func (jw *JSONFileWatcher[T]) reload(filename string, onChange func(old, new T)) {
+	var next T
+	if err := FromJSONFile(filename, &next); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("failed to reload config file; keeping previous value", "file", filename, "error", err)
+		}
+		return
+	}
+	jw.mu.Lock()
+	old := jw.current
+	if reflect.DeepEqual(old, next) {
+		jw.mu.Unlock()
+		return
+	}
+	jw.current = next
+	jw.mu.Unlock()
+	if onChange != nil {
+		onChange(old, next)
+	}
+}
@@ -0,0 +1,154 @@
+//go:build linux
+
+package utilities
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_CREATE | unix.IN_MOVED_TO | unix.IN_MOVED_FROM | unix.IN_DELETE |
+	unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_ATTRIB | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF
+
+type (
+	// inotifyWatcher watches directories with inotify; files are filtered by name within their directory.
+	inotifyWatcher struct {
+		w  *Watcher
+		fd int
+		// file wraps fd for the runtime poller so Close unblocks Read; calling its Fd method would
+		// switch it back to blocking mode.
+		file *os.File
+		mu   sync.Mutex
+		dirs map[int]*inotifyDir
+	}
+
+	inotifyDir struct {
+		path string
+		// all is set when the directory itself was added; otherwise only names are reported.
+		all   bool
+		names map[string]bool
+	}
+)
+
+func newWatcherImpl(w *Watcher) (watcherImpl, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+	iw := &inotifyWatcher{
+		w:    w,
+		fd:   fd,
+		file: os.NewFile(uintptr(fd), "inotify"),
+		dirs: map[int]*inotifyDir{},
+	}
+	w.wg.Add(1)
+	go iw.readLoop()
+	return iw, nil
+}
+
+func (iw *inotifyWatcher) add(path string) error {
+	dir, name := path, ""
+	if fi, err := os.Stat(path); err != nil || !fi.IsDir() {
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		dir, name = filepath.Dir(path), filepath.Base(path)
+	}
+	iw.mu.Lock()
+	defer iw.mu.Unlock()
+	wd, err := unix.InotifyAddWatch(iw.fd, dir, inotifyMask)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	d := iw.dirs[wd]
+	if d == nil {
+		d = &inotifyDir{path: dir, names: map[string]bool{}}
+		iw.dirs[wd] = d
+	}
+	if name == "" {
+		d.all = true
+	} else {
+		d.names[name] = true
+	}
+	return nil
+}
+
+func (iw *inotifyWatcher) close() error {
+	return iw.file.Close()
+}
+
+func (iw *inotifyWatcher) readLoop() {
+	defer iw.w.wg.Done()
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := iw.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				iw.w.emitError(err)
+			}
+			return
+		}
+		for off := 0; off+unix.SizeofInotifyEvent <= n; {
+			raw := (*unix.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			nameBytes := buf[off+unix.SizeofInotifyEvent : off+unix.SizeofInotifyEvent+int(raw.Len)]
+			off += unix.SizeofInotifyEvent + int(raw.Len)
+			iw.handle(int(raw.Wd), raw.Mask, string(bytes.TrimRight(nameBytes, "\x00")))
+		}
+	}
+}
+
+func (iw *inotifyWatcher) handle(wd int, mask uint32, name string) {
+	if mask&unix.IN_Q_OVERFLOW != 0 {
+		iw.w.emitError(ErrWatchOverflow)
+		return
+	}
+	iw.mu.Lock()
+	d := iw.dirs[wd]
+	if d != nil && mask&unix.IN_IGNORED != 0 {
+		delete(iw.dirs, wd)
+	}
+	iw.mu.Unlock()
+	if d == nil || mask&unix.IN_IGNORED != 0 {
+		return
+	}
+
+	var op WatchOp
+	if mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 {
+		op |= WatchCreate
+	}
+	if mask&(unix.IN_MODIFY|unix.IN_CLOSE_WRITE) != 0 {
+		op |= WatchWrite
+	}
+	if mask&(unix.IN_DELETE|unix.IN_DELETE_SELF) != 0 {
+		op |= WatchRemove
+	}
+	if mask&(unix.IN_MOVED_FROM|unix.IN_MOVE_SELF) != 0 {
+		op |= WatchRename
+	}
+	if mask&unix.IN_ATTRIB != 0 {
+		op |= WatchChmod
+	}
+	if op == 0 {
+		return
+	}
+	if name == "" {
+		// the watched directory itself was removed or moved
+		if d.all {
+			iw.w.emit(WatchEvent{Path: d.path, Op: op})
+		}
+		return
+	}
+	iw.mu.Lock()
+	wanted := d.all || d.names[name]
+	iw.mu.Unlock()
+	if wanted {
+		iw.w.emit(WatchEvent{Path: filepath.Join(d.path, name), Op: op})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package utilities
+
+import "errors"
+
+func newWatcherImpl(*Watcher) (watcherImpl, error) {
+	return nil, errors.New("file watching requires inotify and is only supported on Linux")
+}
@@ -0,0 +1,127 @@
+//go:build linux
+
+package utilities_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	utilities "github.com/dan-sherwin/go-utilities"
+)
+
+func nextBatch(t *testing.T, w *utilities.Watcher) []utilities.WatchEvent {
+	t.Helper()
+	select {
+	case batch := <-w.Events():
+		return batch
+	case err := <-w.Errors():
+		t.Fatalf("watch error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for events")
+	}
+	return nil
+}
+
+func TestWatcher_DebouncesFileWrites(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.json")
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := utilities.NewWatcher(utilities.WatchOptions{Debounce: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Add(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// unrelated files in the same directory are ignored
+	_ = os.WriteFile(filepath.Join(dir, "other.txt"), []byte("x"), 0644)
+	for i := 0; i < 5; i++ {
+		_ = os.WriteFile(path, []byte(`{"n":1}`), 0644)
+	}
+	batch := nextBatch(t, w)
+	if len(batch) != 1 || batch[0].Path != path || !batch[0].Op.Has(utilities.WatchWrite) {
+		t.Fatalf("batch=%+v", batch)
+	}
+
+	// an atomic replace shows up as a create of the watched name
+	if err := utilities.WriteFileAtomic(path, []byte(`{"n":2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	batch = nextBatch(t, w)
+	if len(batch) != 1 || batch[0].Path != path || !batch[0].Op.Has(utilities.WatchCreate) {
+		t.Fatalf("after atomic replace batch=%+v", batch)
+	}
+}
+
+func TestWatcher_Directory(t *testing.T) {
+	dir := t.TempDir()
+	w, err := utilities.NewWatcher(utilities.WatchOptions{Debounce: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Add(dir); err != nil {
+		t.Fatal(err)
+	}
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	_ = os.WriteFile(a, nil, 0644)
+	_ = os.Rename(a, b)
+	batch := nextBatch(t, w)
+	if len(batch) != 2 || batch[0].Path != a || !batch[0].Op.Has(utilities.WatchRename) || batch[1].Path != b || !batch[1].Op.Has(utilities.WatchCreate) {
+		t.Fatalf("batch=%+v", batch)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-w.Events(); ok {
+		t.Error("Events should be closed after Close")
+	}
+}
+
+func TestWatchJSONFile_ReloadsWithOldAndNew(t *testing.T) {
+	type config struct {
+		Level string `json:"level"`
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := utilities.ToJSONFile(config{Level: "info"}, path); err != nil {
+		t.Fatal(err)
+	}
+	type change struct{ old, new config }
+	changes := make(chan change, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jw, err := utilities.WatchJSONFile(ctx, path, utilities.WatchOptions{Debounce: 20 * time.Millisecond}, func(old, new config) {
+		changes <- change{old, new}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jw.Current().Level != "info" {
+		t.Fatalf("initial=%+v", jw.Current())
+	}
+
+	// invalid content keeps the previous value
+	_ = os.WriteFile(path, []byte("{not json"), 0644)
+	time.Sleep(100 * time.Millisecond)
+	if err := utilities.ToJSONFileAtomic(config{Level: "debug"}, path); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case c := <-changes:
+		if c.old.Level != "info" || c.new.Level != "debug" || jw.Current().Level != "debug" {
+			t.Errorf("change=%+v current=%+v", c, jw.Current())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload callback")
+	}
+	if err := jw.Close(); err != nil {
+		t.Error(err)
+	}
+}